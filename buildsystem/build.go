@@ -5,6 +5,7 @@ import (
 	"buildenv/pkg/cmd"
 	"buildenv/pkg/env"
 	"buildenv/pkg/fileio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -37,11 +38,23 @@ type PortConfig struct {
 	ExtraHeaderDirs []string   // headers not in standard include path.
 	ExtraLibDirs    []string   // libs not in standard lib path.
 	JobNum          int        // number of jobs to run in parallel
-	TmpDir          string     // for example: ${buildenv}/downloaded/tmp
+	TmpDir          string     // for example: ${buildenv}/downloaded/tmp/zlib@v1.3.1
+	LockedCommit    string     // commit recorded in buildenv.lock, source would be checked out to it.
+	Sha256          string     // sha256 of archive defined in port or recorded in buildenv.lock.
+	Sha512          string     // sha512 of archive defined in port.
+
+	// Used to expand placeholders.
+	BuildType string            // for example: Release, Debug.
+	Vars      map[string]string // Vars defined in project, they can be used as placeholders like ${MY_VAR}.
+
+	// Flags defined in project for current build type, they're empty for dev ports.
+	CFlags   string
+	CXXFlags string
+	LDFlags  string
 }
 
 type BuildSystem interface {
-	Clone(repoUrl, repoRef string) error
+	Clone(repoUrls []string, repoRef string) error
 	Patch() error
 	Configure(buildType string) error
 	Build() error
@@ -51,7 +64,7 @@ type BuildSystem interface {
 	fixBuild() error // Some thirdpartys need extra steps to fix build, for example: nspr.
 	appendBuildEnvs() error
 	removeBuildEnvs() error
-	fillPlaceHolders()
+	expandOptions() error
 	setBuildType(buildType string)
 	getLogPath(suffix string) string
 }
@@ -62,7 +75,7 @@ type FixWork struct {
 }
 
 type BuildConfig struct {
-	Pattern        string   `json:"pattern"`
+	Pattern        Pattern  `json:"pattern"`
 	BuildTool      string   `json:"build_tool"`
 	SystemTools    []string `json:"system_tools"`
 	LibraryType    string   `json:"library_type"`
@@ -94,20 +107,29 @@ func (b BuildConfig) Validate() error {
 	return nil
 }
 
-func (b BuildConfig) Clone(url, ref string) error {
+// Clone clones repo or downloads archive from the urls and their mirrors in order,
+// archive is always named after the first url so that it can be found by any url.
+func (b BuildConfig) Clone(urls []string, ref string) error {
 	// Clone repo only when source dir not exists.
-	if !fileio.PathExists(b.PortConfig.SourceDir) {
-		if strings.HasSuffix(url, ".git") {
-			// Clone repo.
-			command := fmt.Sprintf("git clone --branch %s %s %s --recursive", ref, url, b.PortConfig.SourceDir)
-			title := fmt.Sprintf("[clone %s@%s]", b.PortConfig.LibName, b.PortConfig.LibVersion)
-			if err := cmd.NewExecutor(title, command).Execute(); err != nil {
+	if !fileio.PathExists(b.PortConfig.SourceDir) && len(urls) > 0 {
+		if strings.HasSuffix(urls[0], ".git") {
+			if fileio.Offline() {
+				return fileio.OfflineError{Resource: fmt.Sprintf("%s is not cloned from %s", b.PortConfig.SourceDir, urls[0])}
+			}
+			if err := b.cloneRepo(fileio.MirrorUrls(urls...), ref); err != nil {
 				return err
 			}
 		} else {
+			// Extract into tmp dir of port, leftovers of last failed run are removed first.
+			if err := os.RemoveAll(b.PortConfig.TmpDir); err != nil {
+				return err
+			}
+			defer os.RemoveAll(b.PortConfig.TmpDir)
+
 			// Check and repair resource.
-			archiveName := filepath.Base(url)
-			repair := fileio.NewDownloadRepair(url, archiveName, ".", b.PortConfig.TmpDir, b.PortConfig.DownloadedDir)
+			archiveName := fileio.ArchiveName(urls[0])
+			repair := fileio.NewDownloadRepair(urls[0], archiveName, ".", b.PortConfig.TmpDir, b.PortConfig.DownloadedDir)
+			repair.SetFallbackUrls(urls[1:]...).SetSha256(b.PortConfig.Sha256).SetSha512(b.PortConfig.Sha512)
 			if err := repair.CheckAndRepair(); err != nil {
 				return err
 			}
@@ -130,9 +152,47 @@ func (b BuildConfig) Clone(url, ref string) error {
 		}
 	}
 
+	// Checkout the commit recorded in buildenv.lock, even if the ref has been moved.
+	if b.PortConfig.LockedCommit != "" && fileio.PathExists(filepath.Join(b.PortConfig.SourceDir, ".git")) {
+		if err := cmd.CheckoutCommit(b.PortConfig.SourceDir, b.PortConfig.LockedCommit, b.PortConfig.LibName); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// cloneRepo tries urls in order, half cloned repo is removed before trying next url.
+func (b BuildConfig) cloneRepo(urls []string, ref string) error {
+	title := fmt.Sprintf("[clone %s@%s]", b.PortConfig.LibName, b.PortConfig.LibVersion)
+
+	var errs []error
+	for index, url := range urls {
+		command := fmt.Sprintf("git clone --branch %s %s %s --recursive", ref, url, b.PortConfig.SourceDir)
+		if err := cmd.NewExecutor(title, command).AppendEnv(cmd.GitAuth(url)...).Execute(); err != nil {
+			if len(urls) == 1 {
+				return err
+			}
+
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			if err := os.RemoveAll(b.PortConfig.SourceDir); err != nil {
+				return err
+			}
+			if index < len(urls)-1 {
+				fmt.Printf("[✘] %s is not available: %s, try next url.\n", url, err)
+			}
+			continue
+		}
+
+		if len(urls) > 1 {
+			fmt.Printf("[✔] %s@%s is cloned from %s\n", b.PortConfig.LibName, b.PortConfig.LibVersion, url)
+		}
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
 func (b BuildConfig) Patch() error {
 	if len(b.Patches) == 0 {
 		return nil
@@ -160,7 +220,7 @@ func (b BuildConfig) Patch() error {
 	return nil
 }
 
-func (b *BuildConfig) Install(url, ref, buildType string) error {
+func (b *BuildConfig) Install(urls []string, ref, buildType string) error {
 	// Check if system tool is already installed.
 	if err := b.checkSystemTools(); err != nil {
 		return err
@@ -179,7 +239,9 @@ func (b *BuildConfig) Install(url, ref, buildType string) error {
 	}
 
 	// Replace placeholders with real value, like ${HOST}, ${SYSROOT} etc.
-	b.buildSystem.fillPlaceHolders()
+	if err := b.buildSystem.expandOptions(); err != nil {
+		return err
+	}
 
 	// Some third-party need extra environment variables.
 	if err := b.buildSystem.appendBuildEnvs(); err != nil {
@@ -187,7 +249,7 @@ func (b *BuildConfig) Install(url, ref, buildType string) error {
 	}
 	defer b.buildSystem.removeBuildEnvs()
 
-	if err := b.buildSystem.Clone(url, ref); err != nil {
+	if err := b.buildSystem.Clone(urls, ref); err != nil {
 		return err
 	}
 	if err := b.buildSystem.Patch(); err != nil {
@@ -378,8 +440,14 @@ func (b BuildConfig) fixConfigure() error {
 		}
 
 		// Replace placeholders with real value.
-		script = b.replaceHolders(script)
-		workDir := b.replaceHolders(b.FixConfigure.WorkDir)
+		script, err := b.expand(script)
+		if err != nil {
+			return err
+		}
+		workDir, err := b.expand(b.FixConfigure.WorkDir)
+		if err != nil {
+			return err
+		}
 
 		title := fmt.Sprintf("[before confiure %s]", b.PortConfig.LibName)
 		executor := cmd.NewExecutor(title, script)
@@ -400,8 +468,14 @@ func (b BuildConfig) fixBuild() error {
 		}
 
 		// Replace placeholders with real value.
-		script = b.replaceHolders(script)
-		workDir := b.replaceHolders(b.FixBuild.WorkDir)
+		script, err := b.expand(script)
+		if err != nil {
+			return err
+		}
+		workDir, err := b.expand(b.FixBuild.WorkDir)
+		if err != nil {
+			return err
+		}
 
 		title := fmt.Sprintf("[fix build %s]", b.PortConfig.LibName)
 		executor := cmd.NewExecutor(title, script)
@@ -427,7 +501,17 @@ func (b *BuildConfig) appendBuildEnvs() error {
 
 		key := strings.TrimSpace(item[:index])
 		value := strings.TrimSpace(item[index+1:])
-		value = b.replaceHolders(value)
+
+		// buildenv can wrap CFLAGS and CXXFLAGS, so we need to remove them.
+		if key == "CFLAGS" || key == "CXXFLAGS" {
+			value = strings.ReplaceAll(value, "${CFLAGS}", "")
+			value = strings.ReplaceAll(value, "${CXXFLAGS}", "")
+		}
+
+		value, err := b.expand(value)
+		if err != nil {
+			return err
+		}
 
 		switch key {
 		case "CPATH":
@@ -439,10 +523,6 @@ func (b *BuildConfig) appendBuildEnvs() error {
 			}
 
 		case "CFLAGS", "CXXFLAGS":
-			// buildenv can wrap CFLAGS and CXXFLAGS, so we need to remove them.
-			value = strings.ReplaceAll(value, "${CFLAGS}", "")
-			value = strings.ReplaceAll(value, "${CXXFLAGS}", "")
-
 			current := os.Getenv(key)
 			if strings.TrimSpace(current) == "" {
 				os.Setenv(key, strings.TrimSpace(value))
@@ -518,6 +598,17 @@ func (b *BuildConfig) appendBuildEnvs() error {
 
 		// Append rpath-link.
 		env.AppendRPathLink(filepath.Join(b.PortConfig.InstalledDir, "lib"))
+
+		// Append flags defined in project.
+		if b.PortConfig.CFlags != "" {
+			env.AppendEnv("CFLAGS", b.PortConfig.CFlags)
+		}
+		if b.PortConfig.CXXFlags != "" {
+			env.AppendEnv("CXXFLAGS", b.PortConfig.CXXFlags)
+		}
+		if b.PortConfig.LDFlags != "" {
+			env.AppendEnv("LDFLAGS", b.PortConfig.LDFlags)
+		}
 	}
 
 	return nil
@@ -528,59 +619,6 @@ func (b BuildConfig) removeBuildEnvs() error {
 	return nil
 }
 
-// fillPlaceHolders Replace placeholders with real paths and values.
-func (b *BuildConfig) fillPlaceHolders() {
-	for index, argument := range b.Options {
-		if strings.Contains(argument, "${HOST}") {
-			if b.AsDev {
-				b.Options = slices.Delete(b.Options, index, 1)
-			} else {
-				b.Options[index] = strings.ReplaceAll(argument, "${HOST}", b.PortConfig.CrossTools.Host)
-			}
-		}
-
-		if strings.Contains(argument, "${SYSTEM_NAME}") {
-			if b.AsDev {
-				b.Options = slices.Delete(b.Options, index, 1)
-			} else {
-				b.Options[index] = strings.ReplaceAll(argument, "${SYSTEM_NAME}", strings.ToLower(b.PortConfig.CrossTools.SystemName))
-			}
-		}
-
-		if strings.Contains(argument, "${SYSTEM_PROCESSOR}") {
-			if b.AsDev {
-				b.Options = slices.Delete(b.Options, index, 1)
-			} else {
-				b.Options[index] = strings.ReplaceAll(argument, "${SYSTEM_PROCESSOR}", b.PortConfig.CrossTools.SystemProcessor)
-			}
-		}
-
-		if strings.Contains(argument, "${SYSROOT}") {
-			if b.AsDev {
-				b.Options = slices.Delete(b.Options, index, 1)
-			} else {
-				b.Options[index] = strings.ReplaceAll(argument, "${SYSROOT}", b.PortConfig.CrossTools.RootFS)
-			}
-		}
-
-		if strings.Contains(argument, "${CROSS_PREFIX}") {
-			if b.AsDev {
-				b.Options = slices.Delete(b.Options, index, 1)
-			} else {
-				b.Options[index] = strings.ReplaceAll(argument, "${CROSS_PREFIX}", b.PortConfig.CrossTools.ToolchainPrefix)
-			}
-		}
-
-		if strings.Contains(argument, "${INSTALLED_DIR}") {
-			b.Options[index] = strings.ReplaceAll(argument, "${INSTALLED_DIR}", b.PortConfig.InstalledDir)
-		}
-
-		if strings.Contains(argument, "${SOURCE_DIR}") {
-			b.Options[index] = strings.ReplaceAll(argument, "${SOURCE_DIR}", b.PortConfig.SourceDir)
-		}
-	}
-}
-
 func (b BuildConfig) setBuildType(buildType string) {
 	// Remove all -g and -O flags.
 	cflags := strings.Split(os.Getenv("CFLAGS"), " ")
@@ -595,10 +633,11 @@ func (b BuildConfig) setBuildType(buildType string) {
 		return element == "-g" || element == "-O"
 	})
 
+	// Default flags are in front, then they can be overridden by flags defined in port or project.
 	if b.AsDev {
 		// Set -O3 for dev.
-		cflags = append(cflags, "-O3")
-		cxxflags = append(cxxflags, "-O3")
+		cflags = append([]string{"-O3"}, cflags...)
+		cxxflags = append([]string{"-O3"}, cxxflags...)
 		os.Setenv("CFLAGS", strings.Join(cflags, " "))
 		os.Setenv("CXXFLAGS", strings.Join(cxxflags, " "))
 	} else {
@@ -610,27 +649,13 @@ func (b BuildConfig) setBuildType(buildType string) {
 			flags = "-O3"
 		}
 
-		cflags = append(cflags, flags)
-		cxxflags = append(cxxflags, flags)
+		cflags = append([]string{flags}, cflags...)
+		cxxflags = append([]string{flags}, cxxflags...)
 		os.Setenv("CFLAGS", strings.Join(cflags, " "))
 		os.Setenv("CXXFLAGS", strings.Join(cxxflags, " "))
 	}
 }
 
-func (b BuildConfig) replaceHolders(content string) string {
-	content = strings.ReplaceAll(content, "${HOST}", b.PortConfig.CrossTools.Host)
-	content = strings.ReplaceAll(content, "${SYSTEM_NAME}", b.PortConfig.CrossTools.SystemName)
-	content = strings.ReplaceAll(content, "${SYSTEM_PROCESSOR}", b.PortConfig.CrossTools.SystemProcessor)
-	content = strings.ReplaceAll(content, "${SYSROOT}", b.PortConfig.CrossTools.RootFS)
-	content = strings.ReplaceAll(content, "${CROSS_PREFIX}", b.PortConfig.CrossTools.ToolchainPrefix)
-	content = strings.ReplaceAll(content, "${INSTALLED_DIR}", b.PortConfig.InstalledDir)
-	content = strings.ReplaceAll(content, "${SOURCE_DIR}", b.PortConfig.SourceDir)
-	content = strings.ReplaceAll(content, "${BUILD_DIR}", b.PortConfig.BuildDir)
-	content = strings.ReplaceAll(content, "${PACKAGE_DIR}", b.PortConfig.PackageDir)
-	content = strings.ReplaceAll(content, "${DEV_DIR}", filepath.Join(filepath.Dir(b.PortConfig.InstalledDir), "dev"))
-	return content
-}
-
 func (b BuildConfig) getLogPath(suffix string) string {
 	parentDir := filepath.Dir(b.PortConfig.BuildDir)
 	fileName := filepath.Base(b.PortConfig.BuildDir) + fmt.Sprintf("-%s.log", suffix)
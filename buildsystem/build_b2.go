@@ -93,6 +93,17 @@ func (b b2) Build() error {
 		b.Options = append(b.Options, "linkflags=--sysroot=", b.PortConfig.CrossTools.RootFS)
 	}
 
+	// Append flags defined in project.
+	if b.PortConfig.CFlags != "" {
+		b.Options = append(b.Options, fmt.Sprintf("cflags=\"%s\"", b.PortConfig.CFlags))
+	}
+	if b.PortConfig.CXXFlags != "" {
+		b.Options = append(b.Options, fmt.Sprintf("cxxflags=\"%s\"", b.PortConfig.CXXFlags))
+	}
+	if b.PortConfig.LDFlags != "" {
+		b.Options = append(b.Options, fmt.Sprintf("linkflags=\"%s\"", b.PortConfig.LDFlags))
+	}
+
 	b.prepareBuildInstall()
 
 	// Assemble command.
@@ -90,8 +90,12 @@ func (c cmake) Configure(buildType string) error {
 		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_SYSTEM_PROCESSOR=%s", c.PortConfig.CrossTools.SystemProcessor))
 		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_SYSTEM_NAME=%s", c.PortConfig.CrossTools.SystemName))
 
-		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_C_FLAGS=\"--sysroot=%s ${CMAKE_C_FLAGS}\"", c.PortConfig.CrossTools.RootFS))
-		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_CXX_FLAGS=\"--sysroot=%s ${CMAKE_CXX_FLAGS}\"", c.PortConfig.CrossTools.RootFS))
+		// CFLAGS and CXXFLAGS in environment are ignored when CMAKE_C_FLAGS and CMAKE_CXX_FLAGS are defined,
+		// so flags defined in project should be appended here.
+		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_C_FLAGS=\"%s ${CMAKE_C_FLAGS}\"",
+			strings.TrimSpace("--sysroot="+c.PortConfig.CrossTools.RootFS+" "+c.PortConfig.CFlags)))
+		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_CXX_FLAGS=\"%s ${CMAKE_CXX_FLAGS}\"",
+			strings.TrimSpace("--sysroot="+c.PortConfig.CrossTools.RootFS+" "+c.PortConfig.CXXFlags)))
 
 		c.Options = append(c.Options, fmt.Sprintf("-DCMAKE_FIND_ROOT_PATH=\"%s\"", fmt.Sprintf("%s;%s",
 			c.PortConfig.CrossTools.RootFS, c.PortConfig.InstalledDir)))
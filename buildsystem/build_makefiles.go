@@ -105,10 +105,6 @@ func (m *makefiles) Configure(buildType string) error {
 		configureFile = "Configure"
 	}
 
-	// Replace placeholders.
-	for index, value := range m.Options {
-		m.Options[index] = m.replaceHolders(value)
-	}
 	joinedOptions := strings.Join(m.Options, " ")
 
 	// Execute configure.
@@ -165,6 +165,21 @@ func (m meson) generateCrossFile() (string, error) {
 		bytes.WriteString(fmt.Sprintf("strip = '%s'\n", m.PortConfig.CrossTools.STRIP))
 	}
 
+	// Flags in environment only affect build machine when cross compiling,
+	// so flags defined in project should be written here.
+	if m.PortConfig.CFlags != "" || m.PortConfig.CXXFlags != "" || m.PortConfig.LDFlags != "" {
+		bytes.WriteString("\n[built-in options]\n")
+		writeArgs := func(key, flags string) {
+			if flags != "" {
+				bytes.WriteString(fmt.Sprintf("%s = %s\n", key, mesonArray(flags)))
+			}
+		}
+		writeArgs("c_args", m.PortConfig.CFlags)
+		writeArgs("cpp_args", m.PortConfig.CXXFlags)
+		writeArgs("c_link_args", m.PortConfig.LDFlags)
+		writeArgs("cpp_link_args", m.PortConfig.LDFlags)
+	}
+
 	bytes.WriteString("\n[properties]\n")
 	bytes.WriteString("cross_file = 'true'\n")
 
@@ -189,3 +204,12 @@ func (m meson) generateCrossFile() (string, error) {
 
 	return crossFilePath, nil
 }
+
+// mesonArray converts flags like `-O2 -g` to meson array like `['-O2', '-g']`.
+func mesonArray(flags string) string {
+	var items []string
+	for _, flag := range strings.Fields(flags) {
+		items = append(items, fmt.Sprintf("'%s'", strings.ReplaceAll(flag, "'", "\\'")))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
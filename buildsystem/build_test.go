@@ -0,0 +1,70 @@
+package buildsystem
+
+import (
+	"buildenv/pkg/fileio"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCloneArchivesConcurrently(t *testing.T) {
+	workspaceDir := t.TempDir()
+	ports := []string{"zlib", "x264", "ffmpeg"}
+
+	// Every archive contains a file named after its port.
+	var configs []BuildConfig
+	for _, port := range ports {
+		srcDir := filepath.Join(t.TempDir(), port+"-1.0")
+		if err := os.MkdirAll(srcDir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(srcDir, port+".txt"), []byte(port), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		archivePath := filepath.Join(workspaceDir, "archives", port+"-1.0.tar.gz")
+		if err := fileio.Tarball(archivePath, srcDir, true, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		configs = append(configs, BuildConfig{
+			PortConfig: PortConfig{
+				LibName:       port,
+				LibVersion:    "1.0",
+				DownloadedDir: filepath.Join(workspaceDir, "downloads"),
+				TmpDir:        filepath.Join(workspaceDir, "downloads", "tmp", port+"@1.0"),
+				SourceDir:     filepath.Join(workspaceDir, "buildtrees", port+"@1.0", "src"),
+			},
+		})
+	}
+
+	var waitGroup sync.WaitGroup
+	errs := make([]error, len(configs))
+	for index, config := range configs {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			archivePath := filepath.Join(workspaceDir, "archives", config.PortConfig.LibName+"-1.0.tar.gz")
+			errs[index] = config.Clone([]string{"file:///" + archivePath}, "")
+		}()
+	}
+	waitGroup.Wait()
+
+	for index, config := range configs {
+		if errs[index] != nil {
+			t.Fatal(errs[index])
+		}
+
+		// Source dir should only contain files of its own archive.
+		entries, err := os.ReadDir(config.PortConfig.SourceDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name() != config.PortConfig.LibName+".txt" {
+			t.Fatalf("unexpected files in source dir of %s: %v", config.PortConfig.LibName, entries)
+		}
+		if fileio.PathExists(config.PortConfig.TmpDir) {
+			t.Fatalf("tmp dir of %s should be removed", config.PortConfig.LibName)
+		}
+	}
+}
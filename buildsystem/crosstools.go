@@ -2,6 +2,7 @@ package buildsystem
 
 import (
 	"os"
+	"runtime"
 )
 
 // CrossTools same with `Toolchain` in config/toolchain.go
@@ -82,3 +83,17 @@ func (CrossTools) ClearEnvs() {
 	os.Unsetenv("PKG_CONFIG_PATH")
 	os.Unsetenv("PKG_CONFIG_SYSROOT_DIR")
 }
+
+// HostProcessor returns processor of host machine in the same form as toolchain's system_processor.
+func HostProcessor() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "x86"
+	default:
+		return runtime.GOARCH
+	}
+}
@@ -0,0 +1,145 @@
+package buildsystem
+
+import (
+	"buildenv/pkg/env"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// placeholderRegex matches `$$` and placeholders like `${HOST}`, `${ENV:HOME}`.
+var placeholderRegex = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:[A-Za-z_][A-Za-z0-9_]*)?\}`)
+
+// crossOnlyVars are not defined for dev ports, since dev ports are never cross compiled.
+var crossOnlyVars = []string{"HOST", "SYSROOT", "CROSS_PREFIX"}
+
+// builtinVars returns values of builtin placeholders.
+func (b BuildConfig) builtinVars() map[string]string {
+	vars := map[string]string{
+		"PORT_NAME":     b.PortConfig.LibName,
+		"PORT_VERSION":  b.PortConfig.LibVersion,
+		"BUILD_TYPE":    b.PortConfig.BuildType,
+		"JOBS":          strconv.Itoa(b.PortConfig.JobNum),
+		"INSTALLED_DIR": b.PortConfig.InstalledDir,
+		"SOURCE_DIR":    b.PortConfig.SourceDir,
+		"BUILD_DIR":     b.PortConfig.BuildDir,
+		"PACKAGE_DIR":   b.PortConfig.PackageDir,
+		"DEV_DIR":       filepath.Join(filepath.Dir(b.PortConfig.InstalledDir), "dev"),
+	}
+
+	// Dev ports are built for host machine, so there is no host triple, sysroot and cross prefix.
+	if b.AsDev {
+		vars["SYSTEM_NAME"] = runtime.GOOS
+		vars["SYSTEM_PROCESSOR"] = HostProcessor()
+	} else {
+		vars["HOST"] = b.PortConfig.CrossTools.Host
+		vars["SYSTEM_NAME"] = b.PortConfig.CrossTools.SystemName
+		vars["SYSTEM_PROCESSOR"] = b.PortConfig.CrossTools.SystemProcessor
+		vars["SYSROOT"] = b.PortConfig.CrossTools.RootFS
+		vars["CROSS_PREFIX"] = b.PortConfig.CrossTools.ToolchainPrefix
+	}
+
+	return vars
+}
+
+// expand replaces placeholders in content, they're builtin vars like `${PORT_VERSION}`,
+// vars defined in project like `${MY_VAR}` and environment vars like `${ENV:HOME}`.
+// `$$` is an escaped `$`, and undefined placeholder is an error.
+func (b BuildConfig) expand(content string) (string, error) {
+	return expandVars(content, b.builtinVars(), b.PortConfig.Vars)
+}
+
+// ExpandEnv replaces environment vars like `${ENV:TOKEN}` in content, other placeholders are not defined.
+func ExpandEnv(content string) (string, error) {
+	return expandVars(content, nil, nil)
+}
+
+func expandVars(content string, builtins, userVars map[string]string) (string, error) {
+	var expandErr error
+
+	expanded := placeholderRegex.ReplaceAllStringFunc(content, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+
+		parts := placeholderRegex.FindStringSubmatch(match)
+		name, key := parts[1], strings.TrimPrefix(parts[2], ":")
+
+		// Environment var like `${ENV:HOME}`.
+		if key != "" {
+			if name != "ENV" {
+				expandErr = fmt.Errorf("unknown placeholder %s, only ${ENV:NAME} is supported", match)
+				return match
+			}
+			if value, ok := env.Lookup(key); ok {
+				return value
+			}
+			expandErr = fmt.Errorf("environment variable %s of %s is not defined", key, match)
+			return match
+		}
+
+		if value, ok := builtins[name]; ok {
+			return value
+		}
+
+		// Vars defined in project can refer to builtin vars and environment vars.
+		if value, ok := userVars[name]; ok {
+			value, err := expandVars(value, builtins, nil)
+			if err != nil {
+				expandErr = fmt.Errorf("%s: %w", match, err)
+				return match
+			}
+			return value
+		}
+
+		if slices.Contains(crossOnlyVars, name) {
+			expandErr = fmt.Errorf("placeholder %s is only defined when cross compiling", match)
+		} else {
+			expandErr = fmt.Errorf("placeholder %s is not defined", match)
+		}
+		return match
+	})
+
+	if expandErr != nil {
+		return "", fmt.Errorf("cannot expand %q: %w", content, expandErr)
+	}
+	return expanded, nil
+}
+
+// expandOptions replaces placeholders in options, `${SYSTEM_NAME}` is lowercase in options like
+// `--target-os=${SYSTEM_NAME}`. Options that refer to cross only vars are meaningless for dev ports,
+// like `--host=${HOST}`, they're dropped with notice.
+func (b *BuildConfig) expandOptions() error {
+	builtins := b.builtinVars()
+	builtins["SYSTEM_NAME"] = strings.ToLower(builtins["SYSTEM_NAME"])
+
+	var options []string
+	for _, option := range b.Options {
+		if b.AsDev && refersCrossOnlyVars(option) {
+			fmt.Printf("[%s] option %q is dropped since it's only for cross compiling.\n", b.PortConfig.LibName, option)
+			continue
+		}
+
+		expanded, err := expandVars(option, builtins, b.PortConfig.Vars)
+		if err != nil {
+			return err
+		}
+		options = append(options, expanded)
+	}
+
+	b.Options = options
+	return nil
+}
+
+func refersCrossOnlyVars(content string) bool {
+	for _, parts := range placeholderRegex.FindAllStringSubmatch(content, -1) {
+		if parts[2] == "" && slices.Contains(crossOnlyVars, parts[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package buildsystem
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("BUILDENV_TEST_HOME", "/home/test")
+
+	config := BuildConfig{
+		PortConfig: PortConfig{
+			CrossTools: CrossTools{
+				Host:       "aarch64-linux-gnu",
+				SystemName: "Linux",
+			},
+			LibName:      "zlib",
+			LibVersion:   "1.3.1",
+			BuildType:    "Release",
+			JobNum:       8,
+			InstalledDir: "/buildenv/installed/x86_64-linux^test^Release",
+			Vars: map[string]string{
+				"CACHE_DIR": "${ENV:BUILDENV_TEST_HOME}/cache/${PORT_NAME}",
+			},
+		},
+	}
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"--host=${HOST}", "--host=aarch64-linux-gnu"},
+		{"-DTARGET=${SYSTEM_NAME}", "-DTARGET=Linux"},
+		{"${PORT_NAME}-${PORT_VERSION}", "zlib-1.3.1"},
+		{"make -j${JOBS} BUILD=${BUILD_TYPE}", "make -j8 BUILD=Release"},
+		{"${DEV_DIR}", "/buildenv/installed/dev"},
+		{"--home=${ENV:BUILDENV_TEST_HOME}", "--home=/home/test"},
+		{"--cache=${CACHE_DIR}", "--cache=/home/test/cache/zlib"},
+		{"export PATH=$${PATH}", "export PATH=${PATH}"},
+		{"no placeholders", "no placeholders"},
+	}
+
+	for _, test := range tests {
+		expanded, err := config.expand(test.content)
+		if err != nil {
+			t.Fatalf("expand %q: %s", test.content, err)
+		}
+		if expanded != test.expected {
+			t.Fatalf("expand %q: expected %q, but got %q", test.content, test.expected, expanded)
+		}
+	}
+
+	for _, content := range []string{"${UNDEFINED}", "${ENV:BUILDENV_TEST_UNDEFINED}", "${HOME:PATH}"} {
+		if _, err := config.expand(content); err == nil {
+			t.Fatalf("expand %q: expected error, but got nil", content)
+		}
+	}
+}
+
+func TestExpandOptionsAsDev(t *testing.T) {
+	config := BuildConfig{
+		AsDev:   true,
+		Options: []string{"--host=${HOST}", "--enable-shared", "--sysroot=${SYSROOT}", "--target-os=${SYSTEM_NAME}", "--arch=${SYSTEM_PROCESSOR}", "--prefix=${INSTALLED_DIR}"},
+		PortConfig: PortConfig{
+			CrossTools:   CrossTools{Host: "aarch64-linux-gnu", SystemName: "Linux", SystemProcessor: "aarch64"},
+			LibName:      "x264",
+			InstalledDir: "/buildenv/installed/dev",
+		},
+	}
+
+	if err := config.expandOptions(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dev ports are built for host machine, options only for cross compiling are dropped.
+	expected := "--enable-shared --target-os=" + runtime.GOOS + " --arch=" + HostProcessor() + " --prefix=/buildenv/installed/dev"
+	if joined := strings.Join(config.Options, " "); joined != expected {
+		t.Fatalf("expected options %q, but got %q", expected, joined)
+	}
+}
+
+func TestExpandOptionsSystemName(t *testing.T) {
+	config := BuildConfig{
+		Options: []string{"--target-os=${SYSTEM_NAME}", "--host=${HOST}"},
+		PortConfig: PortConfig{
+			CrossTools: CrossTools{Host: "aarch64-linux-gnu", SystemName: "Linux", SystemProcessor: "aarch64"},
+			LibName:    "ffmpeg",
+		},
+	}
+
+	if err := config.expandOptions(); err != nil {
+		t.Fatal(err)
+	}
+
+	// System name is lowercase in options, but keeps its case elsewhere.
+	if expected := "--target-os=linux --host=aarch64-linux-gnu"; strings.Join(config.Options, " ") != expected {
+		t.Fatalf("expected options %q, but got %q", expected, strings.Join(config.Options, " "))
+	}
+	if expanded, err := config.expand("-DTARGET=${SYSTEM_NAME}"); err != nil || expanded != "-DTARGET=Linux" {
+		t.Fatalf("unexpected expanded: %q, %v", expanded, err)
+	}
+
+	// Cross only vars are not defined for dev ports.
+	config.AsDev = true
+	if _, err := config.expand("--host=${HOST}"); err == nil || !strings.Contains(err.Error(), "only defined when cross compiling") {
+		t.Fatalf("expected error of cross only var, but got %v", err)
+	}
+}
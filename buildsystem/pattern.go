@@ -0,0 +1,209 @@
+package buildsystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PatternAttributes are attributes that can be matched by structured pattern.
+var PatternAttributes = []string{
+	"platform",     // Platform name, like `x86_64-linux-20.04`.
+	"system_name",  // System name of toolchain, like `Linux`, `Windows`.
+	"processor",    // System processor of toolchain, like `x86_64`, `aarch64`.
+	"host",         // Host triple of toolchain, like `aarch64-linux-gnu`.
+	"native",       // `true` when not cross compiling.
+	"library_type", // Library type preferred by project, like `shared`, `static`.
+}
+
+// Pattern selects build_config for platforms, it can be a platform name like `*linux*`,
+// or attributes like `{"system_name": "Linux", "processor": ["aarch64", "armv7"]}`.
+// Every attribute can have alternatives, prefix them with `!` to negate, all attributes must be matched.
+type Pattern struct {
+	Name       string              // Platform name pattern.
+	Attributes map[string][]string // Alternatives of attributes.
+}
+
+// NewPattern returns a platform name pattern.
+func NewPattern(name string) Pattern {
+	return Pattern{Name: name}
+}
+
+// IsStructured reports whether pattern is defined with attributes.
+func (p Pattern) IsStructured() bool {
+	return len(p.Attributes) > 0
+}
+
+// MatchAll reports whether pattern would match any platform.
+func (p Pattern) MatchAll() bool {
+	name := strings.TrimSpace(p.Name)
+	return !p.IsStructured() && (name == "" || name == "*")
+}
+
+// Match checks every attribute with values of current platform,
+// matchName is used to match platform name for name pattern and `platform` attribute.
+func (p Pattern) Match(values map[string]string, matchName func(pattern string) bool) bool {
+	if p.MatchAll() {
+		return true
+	}
+	if !p.IsStructured() {
+		return matchName(p.Name)
+	}
+
+	for attribute, alternatives := range p.Attributes {
+		matched := false
+		hasPositive := false
+		for _, alternative := range alternatives {
+			negated := strings.HasPrefix(alternative, "!")
+			alternative = strings.TrimPrefix(alternative, "!")
+
+			var ok bool
+			if attribute == "platform" {
+				ok = matchName(alternative)
+			} else {
+				ok = MatchGlob(alternative, values[attribute])
+			}
+
+			if negated {
+				// Negated alternative excludes the value.
+				if ok {
+					return false
+				}
+				continue
+			}
+
+			hasPositive = true
+			matched = matched || ok
+		}
+
+		// Only negated alternatives means any other value is matched.
+		if hasPositive && !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the platform name pattern, or compact json of attributes.
+func (p Pattern) String() string {
+	if !p.IsStructured() {
+		return p.Name
+	}
+
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Sprintf("%v", p.Attributes)
+	}
+	return string(bytes)
+}
+
+func (p Pattern) MarshalJSON() ([]byte, error) {
+	if !p.IsStructured() {
+		return json.Marshal(p.Name)
+	}
+
+	// Attributes are sorted by name, single alternative is written as string.
+	var keys []string
+	for key := range p.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for index, key := range keys {
+		if index > 0 {
+			buffer.WriteString(",")
+		}
+
+		var value any = p.Attributes[key]
+		if len(p.Attributes[key]) == 1 {
+			value = p.Attributes[key][0]
+		}
+		keyBytes, _ := json.Marshal(key)
+		valueBytes, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(keyBytes)
+		buffer.WriteString(":")
+		buffer.Write(valueBytes)
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
+
+func (p *Pattern) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		p.Name = name
+		p.Attributes = nil
+		return nil
+	}
+
+	var attributes map[string]any
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return fmt.Errorf("pattern should be a platform name or attributes like {\"system_name\": \"Linux\"}")
+	}
+
+	p.Name = ""
+	p.Attributes = make(map[string][]string)
+	for key, value := range attributes {
+		if !slices.Contains(PatternAttributes, key) {
+			return fmt.Errorf("unknown pattern attribute %q, it should be one of %s",
+				key, strings.Join(PatternAttributes, ", "))
+		}
+
+		switch value := value.(type) {
+		case string:
+			p.Attributes[key] = []string{value}
+
+		case bool:
+			p.Attributes[key] = []string{fmt.Sprintf("%t", value)}
+
+		case []any:
+			for _, item := range value {
+				text, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("alternatives of pattern attribute %q should be strings", key)
+				}
+				p.Attributes[key] = append(p.Attributes[key], text)
+			}
+
+		default:
+			return fmt.Errorf("pattern attribute %q should be a string or a list of strings", key)
+		}
+	}
+
+	return nil
+}
+
+// MatchGlob matches value case-insensitively with pattern that `*` matches any characters.
+func MatchGlob(pattern, value string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	// First part must be prefix and last part must be suffix, others are in order.
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(value, part)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
@@ -0,0 +1,64 @@
+package buildsystem
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	values := map[string]string{
+		"platform":     "aarch64-linux-rk3588",
+		"system_name":  "Linux",
+		"processor":    "aarch64",
+		"host":         "aarch64-linux-gnu",
+		"native":       "false",
+		"library_type": "static",
+	}
+	matchName := func(pattern string) bool {
+		return MatchGlob(pattern, values["platform"])
+	}
+
+	tests := []struct {
+		pattern  string
+		expected bool
+	}{
+		{`""`, true},
+		{`"*linux*"`, true},
+		{`"x86_64*"`, false},
+		{`{"system_name": "linux", "processor": ["aarch64", "armv7"]}`, true},
+		{`{"system_name": "Linux", "processor": ["x86_64", "armv7"]}`, false},
+		{`{"processor": "!aarch64"}`, false},
+		{`{"processor": ["!x86_64", "!armv7"]}`, true},
+		{`{"host": "*-linux-gnu", "native": false}`, true},
+		{`{"native": true}`, false},
+		{`{"platform": ["*rk3588", "*rk3399"], "library_type": "static"}`, true},
+		{`{"platform": "!*rk3588"}`, false},
+	}
+
+	for _, test := range tests {
+		var pattern Pattern
+		if err := json.Unmarshal([]byte(test.pattern), &pattern); err != nil {
+			t.Fatalf("%s: %s", test.pattern, err)
+		}
+		if matched := pattern.Match(values, matchName); matched != test.expected {
+			t.Errorf("%s: expected %t but got %t", test.pattern, test.expected, matched)
+		}
+	}
+}
+
+func TestPatternJSON(t *testing.T) {
+	var pattern Pattern
+	if err := json.Unmarshal([]byte(`{"system_name": "Linux", "processor": ["aarch64", "!armv7"]}`), &pattern); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"processor":["aarch64","!armv7"],"system_name":"Linux"}`; pattern.String() != expected {
+		t.Fatalf("expected %s but got %s", expected, pattern.String())
+	}
+
+	if err := json.Unmarshal([]byte(`{"os": "Linux"}`), &pattern); err == nil {
+		t.Fatal("expected error for unknown attribute")
+	}
+	if err := json.Unmarshal([]byte(`{"processor": 1}`), &pattern); err == nil {
+		t.Fatal("expected error for invalid attribute value")
+	}
+}
@@ -31,6 +31,36 @@ var Commands = []command{
 		Description: "Remove an installed third-party library.",
 		Handler:     handleRemove,
 	},
+	{
+		Name:        "verify",
+		Description: "Verify installed files of third-party libraries.",
+		Handler:     handleVerify,
+	},
+	{
+		Name:        "validate",
+		Description: "Validate platforms, projects, tools and ports in conf repo.",
+		Handler:     handleValidate,
+	},
+	{
+		Name:        "search",
+		Description: "Search third-party libraries in conf repo.",
+		Handler:     handleSearch,
+	},
+	{
+		Name:        "info",
+		Description: "Show details of a third-party library in conf repo.",
+		Handler:     handleInfo,
+	},
+	{
+		Name:        "list",
+		Description: "List installed third-party libraries.",
+		Handler:     handleList,
+	},
+	{
+		Name:        "graph",
+		Description: "Show dependency graph of selected project.",
+		Handler:     handleGraph,
+	},
 	{
 		Name:        "create",
 		Description: "Create platform, project, tool or port.",
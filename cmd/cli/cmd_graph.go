@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"buildenv/config"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func handleGraph(callbacks config.BuildEnvCallbacks) {
+	var (
+		buildType  string
+		format     string
+		updateLock bool
+	)
+
+	cmd := flag.NewFlagSet("graph", flag.ExitOnError)
+	cmd.StringVar(&buildType, "build_type", "Release", "build type, for example: Release, Debug, etc.")
+	cmd.StringVar(&format, "format", "text", "output format, it should be one of text, dot, json.")
+	cmd.BoolVar(&updateLock, "update-lock", false, "resolve ports again without buildenv.lock, the lock file won't be changed.")
+
+	cmd.Usage = func() {
+		fmt.Print("Usage: buildenv graph [options]\n\n")
+		fmt.Println("options:")
+		cmd.PrintDefaults()
+	}
+
+	cmd.Parse(os.Args[2:])
+
+	content, err := callbacks.OnShowGraph(buildType, format, updateLock)
+	if err != nil {
+		config.PrintError(err, "failed to show dependency graph.")
+		os.Exit(1)
+	}
+
+	fmt.Print(content)
+}
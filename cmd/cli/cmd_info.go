@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"buildenv/config"
+	"buildenv/pkg/color"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+func handleInfo(callbacks config.BuildEnvCallbacks) {
+	cmd := flag.NewFlagSet("info", flag.ExitOnError)
+
+	cmd.Usage = func() {
+		fmt.Print("Usage: buildenv info <name@version|name>\n\n")
+		fmt.Println("all versions would be shown when only name is specified, version can also be a constraint like `>=1.2`.")
+	}
+
+	// Check if the <name@version|name> is specified.
+	if len(os.Args) < 3 {
+		fmt.Println("Error: The <name@version|name> must be specified.")
+		cmd.Usage()
+		os.Exit(1)
+	}
+
+	cmd.Parse(os.Args[3:])
+	nameVersion := os.Args[2]
+
+	infos, err := config.ReadPortInfos(nameVersion)
+	if err != nil {
+		config.PrintError(err, "failed to show info of %s.", nameVersion)
+		os.Exit(1)
+	}
+
+	for _, info := range infos {
+		printPortInfo(info)
+	}
+}
+
+func printPortInfo(info config.PortInfo) {
+	fmt.Print(color.Sprintf(color.Blue, "\n%s\n", info.NameVersion()))
+	fmt.Printf("  url: %s\n", info.Url)
+	if info.Ref != "" {
+		fmt.Printf("  ref: %s\n", info.Ref)
+	}
+	if info.HasCMakeConfig {
+		fmt.Printf("  cmake_config: %s@cmake_config.json\n", info.Version)
+	} else {
+		fmt.Println("  cmake_config: none")
+	}
+
+	if len(info.Features) > 0 {
+		var names []string
+		for name := range info.Features {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		fmt.Println("  features:")
+		for _, name := range names {
+			fmt.Printf("    - %s: %s\n", name, info.Features[name].Description)
+		}
+	}
+
+	if len(info.BuildConfigs) == 0 {
+		fmt.Println("  build_configs: none, it would be downloaded and deployed directly.")
+		return
+	}
+
+	fmt.Println("  build_configs:")
+	for _, config := range info.BuildConfigs {
+		fmt.Printf("    - pattern: %s\n", config.Pattern)
+		fmt.Printf("      build_tool: %s\n", config.BuildTool)
+		if config.LibraryType != "" {
+			fmt.Printf("      library_type: %s\n", config.LibraryType)
+		}
+		if len(config.Depedencies) > 0 {
+			fmt.Printf("      dependencies: %s\n", strings.Join(config.Depedencies, ", "))
+		}
+		if len(config.DevDepedencies) > 0 {
+			fmt.Printf("      dev_dependencies: %s\n", strings.Join(config.DevDepedencies, ", "))
+		}
+	}
+}
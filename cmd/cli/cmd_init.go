@@ -2,6 +2,7 @@ package cli
 
 import (
 	"buildenv/config"
+	"buildenv/pkg/fileio"
 	"flag"
 	"fmt"
 	"os"
@@ -9,13 +10,15 @@ import (
 
 func handleInitialize(callbacks config.BuildEnvCallbacks) {
 	var (
-		url    string
-		branch string
+		url     string
+		branch  string
+		offline bool
 	)
 
 	cmd := flag.NewFlagSet("init", flag.ExitOnError)
 	cmd.StringVar(&url, "url", "", "conf repo url")
 	cmd.StringVar(&branch, "branch", "master", "conf repo branch")
+	cmd.BoolVar(&offline, "offline", false, "use conf repo as it is, never sync it from network.")
 
 	cmd.Usage = func() {
 		fmt.Print("Usage: buildenv init [options]\n\n")
@@ -24,6 +27,7 @@ func handleInitialize(callbacks config.BuildEnvCallbacks) {
 	}
 
 	cmd.Parse(os.Args[2:])
+	fileio.SetOffline(offline)
 
 	output, err := callbacks.OnInitBuildEnv(url, branch)
 	if err != nil {
@@ -2,7 +2,6 @@ package cli
 
 import (
 	"buildenv/config"
-	"buildenv/pkg/fileio"
 	"flag"
 	"fmt"
 	"os"
@@ -13,13 +12,21 @@ import (
 
 func handleInstall(callbacks config.BuildEnvCallbacks) {
 	var (
-		buildType string
-		dev       bool
+		buildType  string
+		dev        bool
+		updateLock bool
+		locked     bool
+		jobNum     int
+		offline    bool
 	)
 
 	cmd := flag.NewFlagSet("install", flag.ExitOnError)
-	cmd.StringVar(&buildType, "build_type", "Release", "build type, for example: Release, Debug, etc.")
+	cmd.StringVar(&buildType, "build_type", "", "build types separated by comma, for example: Release, Debug,Release, etc. default is build_types of project or Release.")
+	cmd.BoolVar(&updateLock, "update-lock", false, "resolve ports again and rewrite buildenv.lock.")
+	cmd.BoolVar(&locked, "locked", false, "use buildenv.lock as it is and never update it.")
+	cmd.IntVar(&jobNum, "jobs", 0, "number of jobs to build, default is job_num in buildenv.json.")
 	cmd.BoolVar(&dev, "dev", false, "install a dev third-party.")
+	cmd.BoolVar(&offline, "offline", false, "use local downloads, repos and caches only, missing ones would be reported.")
 
 	cmd.Usage = func() {
 		fmt.Print("Usage: buildenv install <name@version|name>\n\n")
@@ -35,49 +42,98 @@ func handleInstall(callbacks config.BuildEnvCallbacks) {
 	cmd.Parse(os.Args[3:])
 	nameVersion := os.Args[2]
 
-	// Make sure toolchain, rootfs and tools are prepared.
-	args := config.NewSetupArgs(false, true, false).SetBuildType(buildType)
-	buildEnvPath := filepath.Join(config.Dirs.WorkspaceDir, "buildenv.json")
-
-	buildenv := config.NewBuildEnv().SetBuildType(buildType)
-	if err := buildenv.Init(buildEnvPath); err != nil {
-		config.PrintError(err, "failed to init buildenv %s: %s.", nameVersion, err)
-		return
-	}
-	if err := buildenv.Setup(args); err != nil {
+	// Port would be installed for every build type in turn.
+	buildTypes, err := config.ResolveBuildTypes(buildType)
+	if err != nil {
 		config.PrintError(err, "install %s failed.", nameVersion)
-		return
+		os.Exit(1)
 	}
 
-	// Exact check if port to install is exists.
-	if strings.Count(nameVersion, "@") > 0 {
-		parts := strings.Split(nameVersion, "@")
-		portPaths := filepath.Join(config.Dirs.PortsDir, parts[0], parts[1]+".json")
-		if !fileio.PathExists(portPaths) {
-			config.PrintError(fmt.Errorf("port %s is not found", nameVersion), "%s install failed.", nameVersion)
-			return
+	buildenv := config.NewBuildEnv()
+	for _, buildType := range buildTypes {
+		args := config.NewSetupArgs(false, true, false).SetBuildType(buildType).SetUpdateLock(updateLock).SetLocked(locked).SetOffline(offline)
+		buildEnvPath := filepath.Join(config.Dirs.WorkspaceDir, "buildenv.json")
+
+		buildenv = config.NewBuildEnv().SetBuildType(buildType).SetJobNum(jobNum)
+		if err := buildenv.Init(buildEnvPath); err != nil {
+			config.PrintError(err, "failed to init buildenv %s: %s.", nameVersion, err)
+			os.Exit(1)
 		}
-	} else {
-		// Check if port to install is exists in project.
-		index := slices.IndexFunc(buildenv.Project().Ports, func(item string) bool {
-			return strings.Split(item, "@")[0] == nameVersion
-		})
-		if index == -1 {
-			config.PrintError(fmt.Errorf("port %s is not found", nameVersion), "%s install failed.", nameVersion)
-			return
+
+		// Resolve ports of project with buildenv.lock, nothing is downloaded yet.
+		if _, err := buildenv.Project().Resolve(updateLock); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
 		}
-	}
 
-	// Install the port.
-	var port config.Port
-	port.AsDev = dev
-	if err := port.Init(buildenv, nameVersion); err != nil {
-		config.PrintError(err, "install %s failed.", nameVersion)
-		return
+		// Check if port to install is exists, version can be a constraint like `>=1.2.11 <1.3`.
+		if strings.Count(nameVersion, "@") > 0 {
+			if !config.PortExists(nameVersion) {
+				config.PrintError(fmt.Errorf("port %s is not found", nameVersion), "%s install failed.", nameVersion)
+				os.Exit(1)
+			}
+		} else {
+			// Check if port to install is exists in project.
+			index := slices.IndexFunc(buildenv.Project().Ports, func(item string) bool {
+				return strings.Split(item, "@")[0] == nameVersion
+			})
+			if index == -1 {
+				config.PrintError(fmt.Errorf("port %s is not found", nameVersion), "%s install failed.", nameVersion)
+				os.Exit(1)
+			}
+
+			// Use the version defined in project.
+			nameVersion = buildenv.Project().Ports[index]
+		}
+
+		// Check circular dependencies before any download or build starts.
+		graph, err := config.BuildGraph(buildenv, []string{nameVersion}, dev)
+		if err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
+		if err := graph.DetectCycle(); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
+
+		// Make sure toolchain, rootfs and tools are prepared.
+		if err := buildenv.Setup(args); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
+		if err := config.CheckOffline(graph); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
+
+		// Install the port.
+		var port config.Port
+		port.AsDev = dev
+		if err := port.Init(buildenv, nameVersion); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
+		if err := port.Install(false); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
+
+		// Record commits and checksums of installed ports.
+		if !locked {
+			if err := buildenv.Project().WriteLock(); err != nil {
+				config.PrintError(err, "install %s failed.", nameVersion)
+				os.Exit(1)
+			}
+		}
 	}
-	if err := port.Install(false); err != nil {
-		config.PrintError(err, "install %s failed.", nameVersion)
-		return
+
+	// Merge cmake configs of all build types for multi-config generators.
+	if !dev {
+		if err := buildenv.MergeBuildTypes(buildTypes); err != nil {
+			config.PrintError(err, "install %s failed.", nameVersion)
+			os.Exit(1)
+		}
 	}
 
 	config.PrintSuccess("install %s successfully.", nameVersion)
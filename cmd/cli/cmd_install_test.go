@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"buildenv/config"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestInstallExitStatus runs install in a child process like scheduler does,
+// failed install should exit with non-zero status, so that scheduler can find it.
+func TestInstallExitStatus(t *testing.T) {
+	if os.Getenv("BUILDENV_TEST_INSTALL") == "1" {
+		os.Args = []string{"buildenv", "install", "zlib@v1.3.1", "-build_type=Release", "-locked"}
+		handleInstall(config.Callbacks)
+		return
+	}
+
+	workspaceDir := t.TempDir()
+	buildEnvPath := filepath.Join(workspaceDir, "buildenv.json")
+	if err := os.WriteFile(buildEnvPath, []byte(`{"platform_name": "missing", "project_name": "missing"}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInstallExitStatus$")
+	cmd.Dir = workspaceDir
+	cmd.Env = append(os.Environ(), "BUILDENV_TEST_INSTALL=1")
+	output, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected exit status 1 but got %v:\n%s", err, output)
+	}
+}
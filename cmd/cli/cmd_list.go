@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"buildenv/config"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func handleList(callbacks config.BuildEnvCallbacks) {
+	var (
+		platform  string
+		project   string
+		buildType string
+		dev       bool
+		asJson    bool
+	)
+
+	cmd := flag.NewFlagSet("list", flag.ExitOnError)
+	cmd.StringVar(&platform, "platform", "", "list ports installed for the platform only.")
+	cmd.StringVar(&project, "project", "", "list ports installed for the project only.")
+	cmd.StringVar(&buildType, "build_type", "", "list ports installed with the build type only, for example: Release, Debug, etc.")
+	cmd.BoolVar(&dev, "dev", false, "list dev ports only.")
+	cmd.BoolVar(&asJson, "json", false, "print installed ports in json.")
+
+	cmd.Usage = func() {
+		fmt.Print("Usage: buildenv list [options]\n\n")
+		fmt.Println("options:")
+		cmd.PrintDefaults()
+	}
+
+	cmd.Parse(os.Args[2:])
+
+	states, err := config.ListStateFiles()
+	if err != nil {
+		config.PrintError(err, "failed to list installed ports.")
+		os.Exit(1)
+	}
+
+	// Filter installed ports.
+	var filtered []config.StateFile
+	for _, state := range states {
+		if dev && !state.Dev {
+			continue
+		}
+		if platform != "" && state.Platform != platform {
+			continue
+		}
+		if project != "" && state.Project != project {
+			continue
+		}
+		if buildType != "" && !strings.EqualFold(state.BuildType, buildType) {
+			continue
+		}
+		filtered = append(filtered, state)
+	}
+
+	if asJson {
+		if err := printListJson(filtered); err != nil {
+			config.PrintError(err, "failed to list installed ports.")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(filtered) == 0 {
+		fmt.Println("No installed ports found.")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PORT\tPLATFORM\tPROJECT\tBUILD TYPE\tFILES\tSIZE\tFROM")
+	for _, state := range filtered {
+		port := state.FullName()
+		if state.Dev {
+			port += " (dev)"
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", port,
+			orDash(state.Platform), orDash(state.Project), orDash(state.BuildType),
+			len(state.Files), formatSize(state.Size()), orDash(state.From))
+	}
+	writer.Flush()
+}
+
+func printListJson(states []config.StateFile) error {
+	type installedPort struct {
+		Name      string   `json:"name"`
+		Version   string   `json:"version"`
+		Features  []string `json:"features,omitempty"`
+		Dev       bool     `json:"dev"`
+		Platform  string   `json:"platform,omitempty"`
+		Project   string   `json:"project,omitempty"`
+		BuildType string   `json:"build_type,omitempty"`
+		FileCount int      `json:"file_count"`
+		Size      int64    `json:"size"`
+		From      string   `json:"from,omitempty"`
+	}
+
+	ports := []installedPort{}
+	for _, state := range states {
+		ports = append(ports, installedPort{
+			Name:      state.Name,
+			Version:   state.Version,
+			Features:  state.Features,
+			Dev:       state.Dev,
+			Platform:  state.Platform,
+			Project:   state.Project,
+			BuildType: state.BuildType,
+			FileCount: len(state.Files),
+			Size:      state.Size(),
+			From:      state.From,
+		})
+	}
+
+	bytes, err := json.MarshalIndent(ports, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bytes))
+	return nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// formatSize formats size like `1.5 MB`.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
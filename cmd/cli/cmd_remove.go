@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"bufio"
 	"buildenv/buildsystem"
 	"buildenv/config"
 	"buildenv/pkg/fileio"
@@ -19,6 +18,7 @@ func handleRemove(callbacks config.BuildEnvCallbacks) {
 		recurse   bool
 		purge     bool
 		dev       bool
+		force     bool
 	)
 
 	cmd := flag.NewFlagSet("remove", flag.ExitOnError)
@@ -26,6 +26,7 @@ func handleRemove(callbacks config.BuildEnvCallbacks) {
 	cmd.BoolVar(&recurse, "recurse", false, "Remove a third-party with its dependencies also.")
 	cmd.BoolVar(&purge, "purge", false, "Remove a third-party with its package also.")
 	cmd.BoolVar(&dev, "dev", false, "Remove a dev third-party.")
+	cmd.BoolVar(&force, "force", false, "Remove a third-party even if other installed third-parties depend on it.")
 
 	cmd.Usage = func() {
 		fmt.Print("Usage: buildenv remove <name@value|name> [options]\n\n")
@@ -80,8 +81,21 @@ func handleRemove(callbacks config.BuildEnvCallbacks) {
 		portToRemove = buildenv.Project().Ports[index]
 	}
 
+	// Make sure there's no circular dependency before removing recursively.
+	if recurse {
+		graph, err := config.BuildGraph(buildenv, []string{portToRemove}, dev)
+		if err != nil {
+			config.PrintError(err, "%s remove failed.", nameVersion)
+			os.Exit(1)
+		}
+		if err := graph.DetectCycle(); err != nil {
+			config.PrintError(err, "%s remove failed.", nameVersion)
+			os.Exit(1)
+		}
+	}
+
 	// Remove port.
-	if err := removePort(buildenv, portToRemove, dev, purge, recurse); err != nil {
+	if err := removePort(buildenv, portToRemove, dev, purge, recurse, force); err != nil {
 		config.PrintError(err, "%s remove failed.", nameVersion)
 		os.Exit(1)
 	}
@@ -89,7 +103,7 @@ func handleRemove(callbacks config.BuildEnvCallbacks) {
 	config.PrintSuccess("%s remove successfully.", portToRemove)
 }
 
-func removePort(ctx config.Context, nameVersion string, asDev, purge, recurse bool) error {
+func removePort(ctx config.Context, nameVersion string, asDev, purge, recurse, force bool) error {
 	// Check port is configured ok.
 	var port config.Port
 	port.AsDev = asDev
@@ -100,6 +114,22 @@ func removePort(ctx config.Context, nameVersion string, asDev, purge, recurse bo
 		return err
 	}
 
+	// Refuse to remove port that other installed ports still depend on.
+	if !force {
+		dependents, err := config.InstalledDependents(ctx, port)
+		if err != nil {
+			return err
+		}
+		if len(dependents) > 0 {
+			return fmt.Errorf("%s is still required by:\n    - %s\nremove them first or run with --force",
+				port.FullName(), strings.Join(dependents, "\n    - "))
+		}
+	}
+
+	return doRemove(ctx, port, purge, recurse)
+}
+
+func doRemove(ctx config.Context, port config.Port, purge, recurse bool) error {
 	var matchedConfig *buildsystem.BuildConfig
 
 	// No config found, download and deploy it.
@@ -116,14 +146,21 @@ func removePort(ctx config.Context, nameVersion string, asDev, purge, recurse bo
 		}
 	}
 
-	// Try to remove dependencies firstly.
-	if recurse {
-		remove := func(nameVersion string, asDev bool) error {
-			if strings.HasPrefix(nameVersion, port.Name) {
-				return fmt.Errorf("%s's dependencies contains circular dependency: %s",
-					port.NameVersion(), nameVersion)
-			}
+	// Do remove port itself, so that it's no longer a dependent of its dependencies.
+	if err := doRemovePort(ctx, port); err != nil {
+		return err
+	}
 
+	// Remove port's package files.
+	if purge {
+		if err := removePackage(port); err != nil {
+			return err
+		}
+	}
+
+	// Try to remove dependencies then.
+	if recurse && matchedConfig != nil {
+		remove := func(nameVersion string, asDev bool) error {
 			// Check and validate dependency.
 			var port config.Port
 			port.AsDev = asDev
@@ -135,37 +172,38 @@ func removePort(ctx config.Context, nameVersion string, asDev, purge, recurse bo
 				return err
 			}
 
+			// Dependency may be removed already as dependency of another port.
+			if !port.Installed() {
+				return nil
+			}
+
+			// Keep dependency that is still required by other installed ports.
+			dependents, err := config.InstalledDependents(ctx, port)
+			if err != nil {
+				return err
+			}
+			if len(dependents) > 0 {
+				fmt.Printf("skip removing %s, it's still required by %s\n", port.FullName(), strings.Join(dependents, ", "))
+				return nil
+			}
+
 			// Remove dependency.
-			if err := removePort(ctx, nameVersion, asDev, purge, recurse); err != nil {
+			if err := doRemove(ctx, port, purge, recurse); err != nil {
 				return err
 			}
 
 			return nil
 		}
 
-		if matchedConfig != nil {
-			for _, nameVersion := range matchedConfig.Depedencies {
-				if err := remove(nameVersion, false); err != nil {
-					return err
-				}
-			}
-			for _, nameVersion := range matchedConfig.DevDepedencies {
-				if err := remove(nameVersion, true); err != nil {
-					return err
-				}
+		for _, nameVersion := range matchedConfig.Depedencies {
+			if err := remove(nameVersion, port.AsDev); err != nil {
+				return err
 			}
 		}
-	}
-
-	// Do remove port itself.
-	if err := doRemovePort(ctx, port); err != nil {
-		return err
-	}
-
-	// Remove port's package files.
-	if purge {
-		if err := removePackage(ctx, port); err != nil {
-			return err
+		for _, nameVersion := range matchedConfig.DevDepedencies {
+			if err := remove(nameVersion, true); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -174,37 +212,34 @@ func removePort(ctx config.Context, nameVersion string, asDev, purge, recurse bo
 
 func doRemovePort(ctx config.Context, port config.Port) error {
 	// Check if port is installed.
-	var stateFileName string
-	if port.AsDev {
-		stateFileName = fmt.Sprintf("%s^dev.list", port.NameVersion())
-	} else {
-		stateFileName = fmt.Sprintf("%s^%s^%s^%s.list", port.NameVersion(), ctx.Platform().Name, ctx.Project().Name, ctx.BuildType())
-	}
-	stateFilePath := filepath.Join(config.Dirs.WorkspaceDir, "installed", "buildenv", "info", stateFileName)
+	stateFilePath := port.StateFile()
 	if !fileio.PathExists(stateFilePath) {
-		return fmt.Errorf("%s is not installed", port.NameVersion())
+		return fmt.Errorf("%s is not installed", port.FullName())
 	}
 
-	// Open install info file.
-	file, err := os.OpenFile(stateFilePath, os.O_RDONLY, os.ModePerm)
+	// Read install info file.
+	state, err := config.ReadStateFile(stateFilePath)
 	if err != nil {
-		return fmt.Errorf("cannot open install info file: %s", err)
+		return fmt.Errorf("cannot read install info file: %s", err)
 	}
-	defer file.Close()
 
 	platformProject := fmt.Sprintf("%s^%s^%s", ctx.Platform().Name, ctx.Project().Name, ctx.BuildType())
 
-	// Read line by line to remove installed file.
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+	// Files may be installed by other ports also when they're allowed to overwrite.
+	owners, err := config.ReadFileOwners()
+	if err != nil {
+		return fmt.Errorf("cannot read installed files: %s", err)
+	}
 
+	// Remove installed files one by one.
+	for _, file := range state.Files {
 		// CMake project may generate a checksum file after install,
 		// it would be like "/home/phil/.cmake/packages/gflags/4fbe0d242b1c0f095b87a43a7aeaf0d6",
 		// We'll try to remove it also.
-		fileToRemove := line
-		if !fileio.PathExists(line) {
-			fileToRemove = filepath.Join(config.Dirs.WorkspaceDir, "installed", line)
+		fileToRemove := state.InstalledPath(file)
+		if others := owners.Others(fileToRemove, state.FullName()); len(others) > 0 {
+			fmt.Printf("keep %s, it's also installed by %s\n", fileToRemove, others[0].FullName())
+			continue
 		}
 		if err := removeFiles(fileToRemove); err != nil {
 			return fmt.Errorf("cannot remove file: %s", err)
@@ -241,21 +276,9 @@ func doRemovePort(ctx config.Context, port config.Port) error {
 	return nil
 }
 
-func removePackage(ctx config.Context, port config.Port) error {
-	var folderName string
-	if port.AsDev {
-		folderName = port.NameVersion() + "^dev"
-	} else {
-		folderName = fmt.Sprintf("%s^%s^%s^%s",
-			port.NameVersion(),
-			ctx.Platform().Name,
-			ctx.Project().Name,
-			ctx.BuildType(),
-		)
-	}
-
+func removePackage(port config.Port) error {
 	// Remove port's package files.
-	packageDir := filepath.Join(config.Dirs.WorkspaceDir, "packages", folderName)
+	packageDir := port.PackageDir()
 	if err := os.RemoveAll(packageDir); err != nil {
 		return fmt.Errorf("cannot remove package files: %s", err)
 	}
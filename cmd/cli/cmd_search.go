@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"buildenv/config"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func handleSearch(callbacks config.BuildEnvCallbacks) {
+	cmd := flag.NewFlagSet("search", flag.ExitOnError)
+
+	cmd.Usage = func() {
+		fmt.Print("Usage: buildenv search <pattern>\n\n")
+		fmt.Println("pattern can be part of port name or a glob, for example: zlib, lib*.")
+	}
+
+	// Check if the <pattern> is specified.
+	if len(os.Args) < 3 {
+		fmt.Println("Error: The <pattern> must be specified.")
+		cmd.Usage()
+		os.Exit(1)
+	}
+
+	cmd.Parse(os.Args[3:])
+	pattern := os.Args[2]
+
+	summaries, err := config.SearchPorts(pattern)
+	if err != nil {
+		config.PrintError(err, "search %s failed.", pattern)
+		os.Exit(1)
+	}
+
+	if len(summaries) == 0 {
+		fmt.Printf("No ports found for %s.\n", pattern)
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PORT\tVERSIONS")
+	for _, summary := range summaries {
+		fmt.Fprintf(writer, "%s\t%s\n", summary.Name, strings.Join(summary.Versions, ", "))
+	}
+	writer.Flush()
+}
@@ -9,13 +9,19 @@ import (
 
 func handleSetup(callbacks config.BuildEnvCallbacks) {
 	var (
-		silent    bool
-		buildType string
+		silent     bool
+		buildType  string
+		updateLock bool
+		keepGoing  bool
+		offline    bool
 	)
 
 	cmd := flag.NewFlagSet("setup", flag.ExitOnError)
 	cmd.BoolVar(&silent, "silent", false, "run in silent mode, no output log.")
-	cmd.StringVar(&buildType, "build_type", "Release", "build type, for example: Release, Debug, etc.")
+	cmd.StringVar(&buildType, "build_type", "", "build types separated by comma, for example: Release, Debug,Release, etc. default is build_types of project or Release.")
+	cmd.BoolVar(&updateLock, "update-lock", false, "resolve ports again and rewrite buildenv.lock.")
+	cmd.BoolVar(&keepGoing, "keep-going", false, "keep building other ports when some port failed.")
+	cmd.BoolVar(&offline, "offline", false, "use local downloads, repos and caches only, missing ones would be reported.")
 
 	cmd.Usage = func() {
 		fmt.Print("Usage: buildenv setup [options]\n\n")
@@ -24,10 +30,27 @@ func handleSetup(callbacks config.BuildEnvCallbacks) {
 	}
 
 	cmd.Parse(os.Args[2:])
-	args := config.NewSetupArgs(silent, true, true).SetBuildType(buildType)
-	buildenv := config.NewBuildEnv().SetBuildType(buildType)
 
-	if err := buildenv.Setup(args); err != nil {
+	// Every build type would be setup in turn.
+	buildTypes, err := config.ResolveBuildTypes(buildType)
+	if err != nil {
+		config.PrintError(err, "failed to setup buildenv.")
+		return
+	}
+
+	buildenv := config.NewBuildEnv()
+	for _, buildType := range buildTypes {
+		args := config.NewSetupArgs(silent, true, true).SetBuildType(buildType).SetUpdateLock(updateLock).SetKeepGoing(keepGoing).SetOffline(offline)
+		buildenv = config.NewBuildEnv().SetBuildType(buildType)
+
+		if err := buildenv.Setup(args); err != nil {
+			config.PrintError(err, "failed to setup buildenv with build type %s.", buildType)
+			return
+		}
+	}
+
+	// Merge cmake configs of all build types for multi-config generators.
+	if err := buildenv.MergeBuildTypes(buildTypes); err != nil {
 		config.PrintError(err, "failed to setup buildenv.")
 		return
 	}
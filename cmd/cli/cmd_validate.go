@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"buildenv/config"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func handleValidate(callbacks config.BuildEnvCallbacks) {
+	cmd := flag.NewFlagSet("validate", flag.ExitOnError)
+
+	cmd.Usage = func() {
+		fmt.Print("Usage: buildenv validate\n\n")
+		fmt.Println("validate all platforms, projects, tools and ports in conf repo, exit with 1 when any problem found.")
+	}
+
+	cmd.Parse(os.Args[2:])
+
+	issues, err := config.ValidateConfRepo()
+	if err != nil {
+		config.PrintError(err, "failed to validate conf repo.")
+		os.Exit(1)
+	}
+
+	if len(issues) > 0 {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		config.PrintError(fmt.Errorf("%d problems found", len(issues)), "conf repo is invalid.")
+		os.Exit(1)
+	}
+
+	config.PrintSuccess("conf repo is valid.")
+}
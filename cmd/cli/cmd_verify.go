@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"buildenv/config"
+	"buildenv/pkg/fileio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func handleVerify(callbacks config.BuildEnvCallbacks) {
+	var (
+		buildType string
+		repair    bool
+	)
+
+	cmd := flag.NewFlagSet("verify", flag.ExitOnError)
+	cmd.StringVar(&buildType, "build_type", "Release", "build type, for example: Release, Debug, etc.")
+	cmd.BoolVar(&repair, "repair", false, "reinstall ports with missing or modified files from packages or cache dirs.")
+
+	cmd.Usage = func() {
+		fmt.Print("Usage: buildenv verify [name@version|name] [options]\n\n")
+		fmt.Println("options:")
+		cmd.PrintDefaults()
+	}
+
+	// Port to verify is optional, all installed ports would be verified without it.
+	var nameVersion string
+	if len(os.Args) > 2 && !strings.HasPrefix(os.Args[2], "-") {
+		nameVersion = os.Args[2]
+		cmd.Parse(os.Args[3:])
+	} else {
+		cmd.Parse(os.Args[2:])
+	}
+
+	// Verify is read-only, only configuration is loaded, toolchains and buildenv.lock are never touched.
+	buildEnvPath := filepath.Join(config.Dirs.WorkspaceDir, "buildenv.json")
+	if !fileio.PathExists(buildEnvPath) {
+		config.PrintError(fmt.Errorf("buildenv.json is not found, please init buildenv first"), "failed to verify installed ports.")
+		os.Exit(1)
+	}
+	buildenv := config.NewBuildEnv().SetBuildType(buildType)
+	if err := buildenv.Init(buildEnvPath); err != nil {
+		config.PrintError(err, "failed to verify installed ports.")
+		os.Exit(1)
+	}
+
+	states, err := config.ListStateFiles()
+	if err != nil {
+		config.PrintError(err, "failed to verify installed ports.")
+		os.Exit(1)
+	}
+
+	// Only ports of current platform, project and build type, and dev ports would be verified.
+	var toVerify []config.StateFile
+	for _, state := range states {
+		if !state.Dev && (state.Platform != buildenv.Platform().Name ||
+			state.Project != buildenv.Project().Name ||
+			!strings.EqualFold(state.BuildType, buildType)) {
+			continue
+		}
+		if nameVersion != "" && state.Name != nameVersion && state.Name+"@"+state.Version != nameVersion {
+			continue
+		}
+		toVerify = append(toVerify, state)
+	}
+	if nameVersion != "" && len(toVerify) == 0 {
+		config.PrintError(fmt.Errorf("%s is not installed", nameVersion), "failed to verify %s.", nameVersion)
+		os.Exit(1)
+	}
+
+	var (
+		portIssues    int
+		unownedIssues int
+		broken        []config.StateFile
+	)
+	for index := range toVerify {
+		issues, err := toVerify[index].Verify()
+		if err != nil {
+			config.PrintError(err, "failed to verify %s.", toVerify[index].FullName())
+			os.Exit(1)
+		}
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		if len(issues) > 0 {
+			portIssues += len(issues)
+			broken = append(broken, toVerify[index])
+		}
+	}
+
+	// Files are unowned only when they're not recorded by any installed port.
+	if nameVersion == "" {
+		platformProject := fmt.Sprintf("%s^%s^%s", buildenv.Platform().Name, buildenv.Project().Name, buildType)
+		dirs := []string{
+			filepath.Join(config.Dirs.InstalledDir, platformProject),
+			filepath.Join(config.Dirs.InstalledDir, "dev"),
+		}
+		issues, err := config.UnownedFiles(dirs, states)
+		if err != nil {
+			config.PrintError(err, "failed to verify installed ports.")
+			os.Exit(1)
+		}
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		unownedIssues = len(issues)
+	}
+
+	if repair {
+		for _, state := range broken {
+			var port config.Port
+			port.AsDev = state.Dev
+			if err := port.Init(buildenv, state.FullName()); err != nil {
+				config.PrintError(err, "failed to repair %s.", state.FullName())
+				os.Exit(1)
+			}
+			if err := port.Repair(); err != nil {
+				config.PrintError(err, "failed to repair %s.", state.FullName())
+				os.Exit(1)
+			}
+
+			fmt.Printf("repaired %s\n", state.FullName())
+		}
+		portIssues = 0
+	}
+
+	// Unowned files are never removed, since they may be added by hand on purpose.
+	if portIssues+unownedIssues > 0 {
+		config.PrintError(fmt.Errorf("%d issues found", portIssues+unownedIssues), "installed files are not as installed.")
+		os.Exit(1)
+	}
+
+	config.PrintSuccess("installed files are verified.")
+}
@@ -0,0 +1,64 @@
+package menu
+
+import (
+	"buildenv/config"
+	"buildenv/pkg/color"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newGraphModel(callbacks config.BuildEnvCallbacks) *graphModel {
+	content := fmt.Sprintf("\nShow dependency graph.\n"+
+		"-----------------------------------\n"+
+		"%s.\n\n"+
+		"%s",
+		color.Sprintf(color.Blue, "This will resolve ports of current project and print them as a tree"),
+		color.Sprintf(color.Gray, "[↵ -> execute | ctrl+c/q -> quit]"))
+
+	return &graphModel{
+		content:   content,
+		callbacks: callbacks,
+	}
+}
+
+type graphModel struct {
+	content   string
+	graph     string
+	err       error
+	callbacks config.BuildEnvCallbacks
+}
+
+func (g graphModel) Init() tea.Cmd {
+	return nil
+}
+
+func (g graphModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return g, tea.Quit
+
+		case "enter":
+			g.graph, g.err = g.callbacks.OnShowGraph("Release", "text", false)
+			return g, tea.Quit
+
+		case "esc":
+			return MenuModel, nil
+		}
+	}
+	return g, nil
+}
+
+func (g graphModel) View() string {
+	if g.err != nil {
+		return config.SprintError(g.err, "failed to show dependency graph.")
+	}
+
+	if g.graph != "" {
+		return "\n" + g.graph
+	}
+
+	return g.content
+}
@@ -18,6 +18,7 @@ const (
 	menuProjectSelect  string = "Select your current project."
 	menuToolCreate     string = "Create a new tool."
 	menuPortCreate     string = "Create a new port."
+	menuGraph          string = "Show dependency graph of current project."
 	menuIntegrate      string = "Integrate buildenv, then you can run it everywhere."
 	menuAbout          string = "About and usage."
 )
@@ -30,6 +31,7 @@ var menus = []string{
 	menuProjectSelect,
 	menuToolCreate,
 	menuPortCreate,
+	menuGraph,
 	menuIntegrate,
 	menuAbout,
 }
@@ -68,6 +70,7 @@ func newMenuModel(callabcks config.BuildEnvCallbacks) *menuModel {
 	menuModel.models[menuProjectSelect] = newProjectSelectModel(callabcks)
 	menuModel.models[menuToolCreate] = newToolCreateModel(callabcks)
 	menuModel.models[menuPortCreate] = newPortCreateModel(callabcks)
+	menuModel.models[menuGraph] = newGraphModel(callabcks)
 	menuModel.models[menuIntegrate] = newIntegrateModel()
 	menuModel.models[menuAbout] = newAboutModel(callabcks)
 
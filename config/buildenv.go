@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 type Context interface {
@@ -43,6 +44,7 @@ type buildenv struct {
 	platform  Platform
 	project   Project
 	buildType string
+	jobNum    int // Overrides job_num in buildenv.json when greater than 0.
 }
 
 type configData struct {
@@ -52,6 +54,19 @@ type configData struct {
 	ProjectName  string     `json:"project_name"`
 	JobNum       int        `json:"job_num"`
 	CacheDirs    []CacheDir `json:"cache_dirs"`
+
+	// Mirrors are tried before urls with the prefix, like `https://github.com/` -> `http://gitmirror.lan/`.
+	Mirrors map[string]string `json:"mirrors,omitempty"`
+
+	// Offline makes buildenv use local downloads, repos and caches only, like `--offline`.
+	Offline bool `json:"offline,omitempty"`
+
+	// Timeout in seconds and retry times of downloads, default is 30 seconds and 3 times.
+	DownloadTimeout int  `json:"download_timeout,omitempty"`
+	DownloadRetries *int `json:"download_retries,omitempty"`
+
+	// Credentials of hosts for downloads and clones, they take precedence over `~/.netrc`.
+	Credentials []fileio.Credential `json:"credentials,omitempty"`
 }
 
 func (b *buildenv) SetBuildType(buildType string) *buildenv {
@@ -63,16 +78,36 @@ func (b *buildenv) SetBuildType(buildType string) *buildenv {
 	return b
 }
 
+// SetJobNum overrides job_num of buildenv.json, it's used when port is built by scheduler.
+func (b *buildenv) SetJobNum(jobNum int) *buildenv {
+	b.jobNum = jobNum
+	return b
+}
+
 func (b *buildenv) Setup(args SetupArgs) error {
+	if args.Offline() {
+		fileio.SetOffline(true)
+	}
+
 	buildEnvPath := filepath.Join(Dirs.WorkspaceDir, "buildenv.json")
 	if err := b.Init(buildEnvPath); err != nil {
 		return err
 	}
 
-	// init and setup platform.
+	// init platform and project.
 	if err := b.platform.Init(b, b.PlatformName); err != nil {
 		return err
 	}
+	if err := b.project.Init(b, b.ProjectName); err != nil {
+		return err
+	}
+
+	// Conflicts and circular dependencies should fail before toolchain, rootfs and tools are downloaded.
+	if _, err := b.project.Resolve(args.UpdateLock()); err != nil {
+		return err
+	}
+
+	// setup platform.
 	if err := b.platform.Setup(args); err != nil {
 		return err
 	}
@@ -80,10 +115,7 @@ func (b *buildenv) Setup(args SetupArgs) error {
 	// Append runtime bin path to PATH, this is required by some third-party libraries during build.
 	os.Setenv("PATH", filepath.Join(Dirs.InstalledDir, "dev", "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
 
-	// init and setup project.
-	if err := b.project.Init(b, b.ProjectName); err != nil {
-		return err
-	}
+	// setup project.
 	if err := b.project.Setup(args); err != nil {
 		return err
 	}
@@ -100,6 +132,7 @@ func (b buildenv) SyncRepo(repo, ref string) (string, error) {
 		commandLine := strings.Join(commands, " && ")
 		executor := cmd.NewExecutor("[clone]", commandLine)
 		executor.SetWorkDir(Dirs.WorkspaceDir)
+		executor.AppendEnv(cmd.GitAuth(repo)...)
 
 		output, err := executor.ExecuteOutput()
 		if err != nil {
@@ -120,6 +153,7 @@ func (b buildenv) SyncRepo(repo, ref string) (string, error) {
 		commandLine := strings.Join(commands, " && ")
 		executor := cmd.NewExecutor("[sync]", commandLine)
 		executor.SetWorkDir(workDir)
+		executor.AppendEnv(cmd.GitAuth(repo)...)
 
 		output, err := executor.ExecuteOutput()
 		if err != nil {
@@ -129,8 +163,16 @@ func (b buildenv) SyncRepo(repo, ref string) (string, error) {
 		return output, nil
 	}
 
-	// Clone or git checkout repo.
+	// Conf repo is used as it is in offline mode.
 	confDir := filepath.Join(Dirs.WorkspaceDir, "conf")
+	if b.Offline || fileio.Offline() {
+		if fileio.PathExists(confDir) {
+			return "conf repo is not synced in offline mode.", nil
+		}
+		return "", fileio.OfflineError{Resource: fmt.Sprintf("conf repo is not cloned from %s", repo)}
+	}
+
+	// Clone or git checkout repo.
 	if fileio.PathExists(confDir) {
 		if fileio.PathExists(filepath.Join(confDir, ".git")) {
 			return syncFunc(confDir)
@@ -158,12 +200,24 @@ if(NOT CMAKE_BUILD_TYPE)
 	set(CMAKE_BUILD_TYPE "Release")
 endif()
 
+# Multi-config generators like "Ninja Multi-Config" need all configuration types.
+get_property(BUILDENV_MULTI_CONFIG GLOBAL PROPERTY GENERATOR_IS_MULTI_CONFIG)
+if(BUILDENV_MULTI_CONFIG)
+	if(CMAKE_CONFIGURATION_TYPES)
+		string(REPLACE ";" "," BUILDENV_BUILD_TYPES "${CMAKE_CONFIGURATION_TYPES}")
+	else()
+		set(BUILDENV_BUILD_TYPES "Debug,Release")
+	endif()
+else()
+	set(BUILDENV_BUILD_TYPES "${CMAKE_BUILD_TYPE}")
+endif()
+
 # Setup buildenv during configuration.
 set(HOME_DIR "${CMAKE_CURRENT_LIST_DIR}/..")
 find_program(BUILDENV buildenv PATHS ${HOME_DIR})
 if(BUILDENV)
 	execute_process(
-		COMMAND ${BUILDENV} setup -silent -build_type=${CMAKE_BUILD_TYPE}
+		COMMAND ${BUILDENV} setup -silent -build_type=${BUILDENV_BUILD_TYPES}
 		WORKING_DIRECTORY ${HOME_DIR}
 	)
 endif()` + "\n")
@@ -201,9 +255,15 @@ get_filename_component(BUILDENV_ROOT_DIR "${_CURRENT_DIR}" PATH)`))
 		return "", err
 	}
 
-	toolchain.WriteString("\n# Add `installed dir` into library search paths.\n")
-	platformProject := fmt.Sprintf("%s^%s^${CMAKE_BUILD_TYPE}", b.PlatformName, b.ProjectName)
-	installedDir := fmt.Sprintf("${BUILDENV_ROOT_DIR}/installed/%s", platformProject)
+	toolchain.WriteString("\n# Add `installed dir` into library search paths, it merges all build types for multi-config generators.\n")
+	toolchain.WriteString("if(BUILDENV_MULTI_CONFIG)\n")
+	toolchain.WriteString(fmt.Sprintf("\tset(BUILDENV_INSTALLED_DIR \"${BUILDENV_ROOT_DIR}/installed/%s^%s^%s\")\n",
+		b.PlatformName, b.ProjectName, multiConfigFolder))
+	toolchain.WriteString("else()\n")
+	toolchain.WriteString(fmt.Sprintf("\tset(BUILDENV_INSTALLED_DIR \"${BUILDENV_ROOT_DIR}/installed/%s^%s^${CMAKE_BUILD_TYPE}\")\n",
+		b.PlatformName, b.ProjectName))
+	toolchain.WriteString("endif()\n")
+	installedDir := "${BUILDENV_INSTALLED_DIR}"
 	toolchain.WriteString(fmt.Sprintf("list(APPEND CMAKE_FIND_ROOT_PATH \"%s\")\n", installedDir))
 	toolchain.WriteString(fmt.Sprintf("list(APPEND CMAKE_PREFIX_PATH \"%s\")\n", installedDir))
 	toolchain.WriteString(fmt.Sprintf("set(ENV{PKG_CONFIG_PATH} \"%s/lib/pkgconfig%s$ENV{PKG_CONFIG_PATH}\")\n",
@@ -237,6 +297,24 @@ get_filename_component(BUILDENV_ROOT_DIR "${_CURRENT_DIR}" PATH)`))
 		toolchain.WriteString(fmt.Sprintf("set (ENV{%s} \"%s\")\n", parts[0], parts[1]))
 		environment.WriteString(fmt.Sprintf("export %s=%s\n", parts[0], parts[1]))
 	}
+
+	// Define compiler and linker flags for project, flags of every build type are appended to cmake's init flags.
+	if len(b.project.CFlags) > 0 || len(b.project.CXXFlags) > 0 || len(b.project.LDFlags) > 0 {
+		toolchain.WriteString("\n# Define compiler and linker flags for project.\n")
+		b.project.CFlags.writeCMake(&toolchain, "CMAKE_C_FLAGS")
+		b.project.CXXFlags.writeCMake(&toolchain, "CMAKE_CXX_FLAGS")
+		b.project.LDFlags.writeCMake(&toolchain, "CMAKE_EXE_LINKER_FLAGS", "CMAKE_SHARED_LINKER_FLAGS", "CMAKE_MODULE_LINKER_FLAGS")
+
+		environment.WriteString("\n# Define compiler and linker flags for project.\n")
+		writeIfNotEmpty := func(key string, flags BuildFlags) {
+			if value := flags.Resolve(b.BuildType()); value != "" {
+				environment.WriteString(fmt.Sprintf("export %s=\"${%s} %s\"\n", key, key, value))
+			}
+		}
+		writeIfNotEmpty("CFLAGS", b.project.CFlags)
+		writeIfNotEmpty("CXXFLAGS", b.project.CXXFlags)
+		writeIfNotEmpty("LDFLAGS", b.project.LDFlags)
+	}
 	for index, item := range b.project.MicroVars {
 		if index == 0 {
 			toolchain.WriteString("\n# Define micro vars for project.\n")
@@ -306,6 +384,21 @@ func (b *buildenv) Init(buildEnvPath string) error {
 		}
 	}
 
+	// Mirrors are applied to all downloads and clones.
+	if err := fileio.SetMirrors(b.configData.Mirrors); err != nil {
+		return err
+	}
+	if b.configData.Offline {
+		fileio.SetOffline(true)
+	}
+	fileio.SetDownloadOptions(fileio.DownloadOptions{
+		Timeout: time.Duration(b.configData.DownloadTimeout) * time.Second,
+		Retries: b.configData.DownloadRetries,
+	})
+	if err := setupCredentials(b.configData.Credentials); err != nil {
+		return err
+	}
+
 	// Init platform with platform name.
 	if err := b.platform.Init(b, b.configData.PlatformName); err != nil {
 		return err
@@ -382,6 +475,9 @@ func (b buildenv) BuildType() string {
 }
 
 func (b buildenv) JobNum() int {
+	if b.jobNum > 0 {
+		return b.jobNum
+	}
 	return b.configData.JobNum
 }
 
@@ -251,6 +251,38 @@ func (c callbackImpl) OnCreatePort(portNameVersion string) error {
 	return nil
 }
 
+func (c callbackImpl) OnShowGraph(buildType, format string, updateLock bool) (string, error) {
+	// Resolve ports as what setup would install, but never touch buildenv.lock.
+	args := NewSetupArgs(true, false, false).SetBuildType(buildType).SetUpdateLock(updateLock).SetLocked(true)
+	buildenv := NewBuildEnv().SetBuildType(buildType)
+	if err := buildenv.Setup(args); err != nil {
+		return "", err
+	}
+
+	graph, err := BuildGraph(buildenv, buildenv.Project().Ports, false)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case "text":
+		return graph.Text(), nil
+
+	case "dot":
+		return graph.Dot(buildenv.ProjectName), nil
+
+	case "json":
+		bytes, err := graph.JSON()
+		if err != nil {
+			return "", err
+		}
+		return string(bytes) + "\n", nil
+
+	default:
+		return "", fmt.Errorf("unsupported graph format: %s, it should be one of text, dot, json", format)
+	}
+}
+
 func (c callbackImpl) About(version string) string {
 	toolchainPath, _ := filepath.Abs("scripts/toolchain_file.cmake")
 	environmentPath, _ := filepath.Abs("scripts/environment")
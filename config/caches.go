@@ -9,9 +9,24 @@ import (
 )
 
 type CacheDir struct {
-	Dir      string `json:"dir"`
-	Readable bool   `json:"readable"`
-	Writable bool   `json:"writable"`
+	Dir              string `json:"dir"`
+	Readable         bool   `json:"readable"`
+	Writable         bool   `json:"writable"`
+	Compression      string `json:"compression,omitempty"`       // Compression of written packages, `gzip` (default) or `zstd`.
+	CompressionLevel int    `json:"compression_level,omitempty"` // Level of gzip (1~9) or zstd (1~22), default level is used when it's 0.
+}
+
+// cacheSuffixes are suffixes of packages for compressions, packages of all compressions can be read.
+var cacheSuffixes = map[string]string{
+	"gzip": ".tar.gz",
+	"zstd": ".tar.zst",
+}
+
+func (c CacheDir) suffix() string {
+	if c.Compression == "" {
+		return cacheSuffixes["gzip"]
+	}
+	return cacheSuffixes[c.Compression]
 }
 
 func (c CacheDir) Validate() error {
@@ -27,11 +42,34 @@ func (c CacheDir) Validate() error {
 	if c.Writable && !fileio.IsWritable(c.Dir) {
 		return fmt.Errorf("cache dir %s is not writable", c.Dir)
 	}
+	if c.suffix() == "" {
+		return fmt.Errorf("compression of cache dir %s should be gzip or zstd, but got %s", c.Dir, c.Compression)
+	}
+	if err := fileio.ValidateCompressionLevel(c.suffix(), c.CompressionLevel); err != nil {
+		return fmt.Errorf("cache dir %s: %w", c.Dir, err)
+	}
 	return nil
 }
 
+// archivePath returns path of package in cache dir, archiveName has no suffix. Package of the
+// compression of cache dir is preferred, packages written with other compression are also accepted.
+func (c CacheDir) archivePath(platformName, projectName, buildType, archiveName string) string {
+	archiveDir := filepath.Join(c.Dir, platformName, projectName, buildType)
+
+	preferred := filepath.Join(archiveDir, archiveName+c.suffix())
+	if fileio.PathExists(preferred) {
+		return preferred
+	}
+	for _, suffix := range cacheSuffixes {
+		if archivePath := filepath.Join(archiveDir, archiveName+suffix); fileio.PathExists(archivePath) {
+			return archivePath
+		}
+	}
+	return preferred
+}
+
 func (c CacheDir) Read(platformName, projectName, buildType, archiveName, destDir string) (bool, error) {
-	archivePath := filepath.Join(c.Dir, platformName, projectName, buildType, archiveName)
+	archivePath := c.archivePath(platformName, projectName, buildType, archiveName)
 	if !fileio.PathExists(archivePath) {
 		return false, nil // not an error even not exist.
 	}
@@ -47,7 +85,7 @@ func (c CacheDir) Read(platformName, projectName, buildType, archiveName, destDi
 	return true, nil
 }
 
-func (c CacheDir) Write(packageDir string) error {
+func (c CacheDir) Write(packageDir, archiveName string) error {
 	if !c.Writable {
 		return nil
 	}
@@ -63,24 +101,24 @@ func (c CacheDir) Write(packageDir string) error {
 	}
 
 	var (
-		nameVersion  = parts[0]
 		platformName = parts[1]
 		projectName  = parts[2]
 		buildType    = parts[3]
 	)
 
-	archiveName := fmt.Sprintf("%s.tar.gz", nameVersion)
-	destPath := filepath.Join(os.TempDir(), archiveName)
-	if err := fileio.Targz(destPath, packageDir, false); err != nil {
+	destPath := filepath.Join(os.TempDir(), archiveName+c.suffix())
+	if err := fileio.Tarball(destPath, packageDir, false, c.CompressionLevel); err != nil {
 		return err
 	}
 	defer os.Remove(destPath)
 
 	destDir := filepath.Join(c.Dir, platformName, projectName, buildType)
 
-	// Remove the old tarball.
-	if err := os.RemoveAll(filepath.Join(destDir, archiveName)); err != nil {
-		return err
+	// Remove the old tarballs, including those written with other compression.
+	for _, suffix := range cacheSuffixes {
+		if err := os.RemoveAll(filepath.Join(destDir, archiveName+suffix)); err != nil {
+			return err
+		}
 	}
 
 	// Create the dir if not exist.
@@ -89,7 +127,7 @@ func (c CacheDir) Write(packageDir string) error {
 	}
 
 	// Move the tarball to cache dir.
-	if err := fileio.CopyFile(destPath, filepath.Join(destDir, archiveName)); err != nil {
+	if err := fileio.CopyFile(destPath, filepath.Join(destDir, archiveName+c.suffix())); err != nil {
 		return err
 	}
 
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheDirCompression(t *testing.T) {
+	packageDir := filepath.Join(t.TempDir(), "zlib@v1.3.1^x86_64-linux^test_project^Release")
+	if err := os.MkdirAll(filepath.Join(packageDir, "include"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packageDir, "include", "zlib.h"), []byte("zlib"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Package written with gzip is readable by cache dir of zstd.
+	gzipDir := CacheDir{Dir: t.TempDir(), Readable: true, Writable: true}
+	if err := gzipDir.Write(packageDir, "zlib@v1.3.1"); err != nil {
+		t.Fatal(err)
+	}
+	zstdDir := gzipDir
+	zstdDir.Compression, zstdDir.CompressionLevel = "zstd", 19
+	if err := zstdDir.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "package")
+	if ok, err := zstdDir.Read("x86_64-linux", "test_project", "Release", "zlib@v1.3.1", destDir); !ok || err != nil {
+		t.Fatalf("expected package to be read from .tar.gz: %v", err)
+	}
+	if !fileio.PathExists(filepath.Join(destDir, "include", "zlib.h")) {
+		t.Fatal("zlib.h is not extracted")
+	}
+
+	// Package written with zstd replaces the one of gzip.
+	if err := zstdDir.Write(packageDir, "zlib@v1.3.1"); err != nil {
+		t.Fatal(err)
+	}
+	archiveDir := filepath.Join(zstdDir.Dir, "x86_64-linux", "test_project", "Release")
+	if fileio.PathExists(filepath.Join(archiveDir, "zlib@v1.3.1.tar.gz")) || !fileio.PathExists(filepath.Join(archiveDir, "zlib@v1.3.1.tar.zst")) {
+		t.Fatal("expected only zlib@v1.3.1.tar.zst in cache dir")
+	}
+	if ok, err := gzipDir.Read("x86_64-linux", "test_project", "Release", "zlib@v1.3.1", destDir); !ok || err != nil {
+		t.Fatalf("expected package to be read from .tar.zst: %v", err)
+	}
+	if !fileio.PathExists(filepath.Join(destDir, "include", "zlib.h")) {
+		t.Fatal("zlib.h is not extracted")
+	}
+
+	for _, cacheDir := range []CacheDir{
+		{Dir: zstdDir.Dir, Compression: "lz4"},
+		{Dir: zstdDir.Dir, Compression: "zstd", CompressionLevel: 23},
+		{Dir: zstdDir.Dir, CompressionLevel: 10},
+	} {
+		if err := cacheDir.Validate(); err == nil {
+			t.Fatalf("expected error of cache dir: %+v", cacheDir)
+		}
+	}
+}
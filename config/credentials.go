@@ -0,0 +1,71 @@
+package config
+
+import (
+	"buildenv/buildsystem"
+	"buildenv/pkg/env"
+	"buildenv/pkg/fileio"
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// setupCredentials expands secrets like `${ENV:TOKEN}` in credentials, then applies them
+// and netrc to all downloads and clones.
+func setupCredentials(credentials []fileio.Credential) error {
+	var expanded []fileio.Credential
+	for index, credential := range credentials {
+		var err error
+		expand := func(value string) string {
+			if err != nil || value == "" {
+				return value
+			}
+			value, err = buildsystem.ExpandEnv(value)
+			return value
+		}
+
+		credential.Username = expand(credential.Username)
+		credential.Password = expand(credential.Password)
+		credential.Token = expand(credential.Token)
+		if len(credential.Headers) > 0 {
+			headers := make(map[string]string)
+			for key, value := range credential.Headers {
+				headers[key] = expand(value)
+			}
+			credential.Headers = headers
+		}
+		if err != nil {
+			return fmt.Errorf("credential %d of %s: %w", index, credential.Host, err)
+		}
+
+		expanded = append(expanded, credential)
+	}
+
+	if err := fileio.SetCredentials(expanded); err != nil {
+		return fmt.Errorf("invalid credentials: %w", err)
+	}
+
+	if netrcPath := netrcPath(); netrcPath != "" {
+		if err := fileio.LoadNetrc(netrcPath); err != nil {
+			return fmt.Errorf("cannot read %s: %w", netrcPath, err)
+		}
+	}
+	return nil
+}
+
+// netrcPath returns path of netrc, it's `$NETRC` or `~/.netrc` (`%USERPROFILE%\_netrc` on windows).
+func netrcPath() string {
+	if path, ok := env.Lookup("NETRC"); ok && path != "" {
+		return path
+	}
+
+	if runtime.GOOS == "windows" {
+		if home, ok := env.Lookup("USERPROFILE"); ok {
+			return filepath.Join(home, "_netrc")
+		}
+		return ""
+	}
+	if home, ok := env.Lookup("HOME"); ok {
+		return filepath.Join(home, ".netrc")
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetupCredentials(t *testing.T) {
+	t.Setenv("NETRC", filepath.Join(t.TempDir(), "not_exists"))
+	t.Setenv("ARTIFACTORY_TOKEN", "secret-token")
+	defer fileio.SetCredentials(nil)
+
+	credentials := []fileio.Credential{
+		{Host: "artifactory.lan", Token: "${ENV:ARTIFACTORY_TOKEN}"},
+		{Host: "git.lan", Headers: map[string]string{"PRIVATE-TOKEN": "${ENV:ARTIFACTORY_TOKEN}"}},
+	}
+	if err := setupCredentials(credentials); err != nil {
+		t.Fatal(err)
+	}
+
+	// Placeholders in buildenv.json should be kept as they are.
+	if credentials[0].Token != "${ENV:ARTIFACTORY_TOKEN}" || credentials[1].Headers["PRIVATE-TOKEN"] != "${ENV:ARTIFACTORY_TOKEN}" {
+		t.Fatalf("credentials are modified: %+v", credentials)
+	}
+	if value := fileio.AuthHeaders("https://artifactory.lan/file.tar.gz")["Authorization"]; value != "Bearer secret-token" {
+		t.Fatalf("unexpected Authorization: %q", value)
+	}
+	if value := fileio.AuthHeaders("https://git.lan/repo.git")["PRIVATE-TOKEN"]; value != "secret-token" {
+		t.Fatalf("unexpected PRIVATE-TOKEN: %q", value)
+	}
+	if redacted := fileio.Redact("token: secret-token"); redacted != "token: ***" {
+		t.Fatalf("token is not redacted: %q", redacted)
+	}
+
+	// Undefined environment variable is an error.
+	if err := setupCredentials([]fileio.Credential{{Host: "artifactory.lan", Token: "${ENV:NOT_DEFINED_TOKEN}"}}); err == nil {
+		t.Fatal("expected error of undefined environment variable")
+	}
+}
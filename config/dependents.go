@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// InstalledDependents returns installed ports that depend on the port directly,
+// only ports installed for current platform, project, build type and dev ports are checked.
+func InstalledDependents(ctx Context, port Port) ([]string, error) {
+	states, err := ListStateFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var dependents []string
+	for _, state := range states {
+		if !state.Dev && (state.Platform != ctx.Platform().Name ||
+			state.Project != ctx.Project().Name ||
+			state.BuildType != ctx.BuildType()) {
+			continue
+		}
+
+		var installed Port
+		installed.AsDev = state.Dev
+		installed.AsSubDep = true
+		nameVersion := state.Name + "@" + state.Version + formatFeatures(state.Features)
+		if err := installed.Init(ctx, nameVersion); err != nil {
+			// Port may have been removed from conf repo, its dependencies cannot be known any more.
+			continue
+		}
+		if samePort(installed, port) || len(installed.BuildConfigs) == 0 {
+			continue
+		}
+
+		matchedConfig, err := installed.MatchedConfig()
+		if err != nil {
+			continue
+		}
+
+		depends, err := dependsOn(ctx, port, matchedConfig.Depedencies, installed.AsDev)
+		if err != nil {
+			return nil, err
+		}
+		if !depends {
+			depends, err = dependsOn(ctx, port, matchedConfig.DevDepedencies, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if depends {
+			label := installed.FullName()
+			if installed.AsDev {
+				label += " (dev)"
+			}
+			dependents = append(dependents, label)
+		}
+	}
+
+	slices.Sort(dependents)
+	return dependents, nil
+}
+
+// dependsOn reports whether any of the dependencies is resolved to the port,
+// features are ignored since dependency may request features different from installed ones.
+func dependsOn(ctx Context, port Port, dependencies []string, asDev bool) (bool, error) {
+	for _, nameVersion := range dependencies {
+		name, _, err := splitNameVersion(nameVersion)
+		if err != nil {
+			return false, err
+		}
+		if name != port.Name || asDev != port.AsDev {
+			continue
+		}
+
+		var dependency Port
+		dependency.AsDev = asDev
+		dependency.AsSubDep = true
+		if err := dependency.Init(ctx, nameVersion); err != nil {
+			return false, fmt.Errorf("%s: %w", nameVersion, err)
+		}
+		if samePort(dependency, port) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// samePort reports whether ports are the same name and version, no matter which features are selected.
+func samePort(a, b Port) bool {
+	return a.Name == b.Name && a.Version == b.Version && a.AsDev == b.AsDev
+}
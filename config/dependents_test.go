@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestInstalledDependents(t *testing.T) {
+	portsDir, installedDir := Dirs.PortsDir, Dirs.InstalledDir
+	Dirs.PortsDir, Dirs.InstalledDir = t.TempDir(), t.TempDir()
+	defer func() { Dirs.PortsDir, Dirs.InstalledDir = portsDir, installedDir }()
+
+	writeTestPort(t, "a@1", `"c@3"`)
+	writeTestPort(t, "b@2", `"c@3"`)
+	writeTestPort(t, "c@3", ``)
+
+	ctx := NewBuildEnv()
+	install := func(nameVersion string) Port {
+		var port Port
+		if err := port.Init(ctx, nameVersion); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeStateFile(port.stateFile, "source", "", []string{"lib/" + port.Name + ".a"}); err != nil {
+			t.Fatal(err)
+		}
+		return port
+	}
+	a, _, c := install("a@1"), install("b@2"), install("c@3")
+
+	dependents, err := InstalledDependents(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(dependents, []string{"a@1", "b@2"}) {
+		t.Fatalf("unexpected dependents: %v", dependents)
+	}
+
+	// Port is no longer required after its dependents are removed.
+	if err := os.Remove(a.stateFile); err != nil {
+		t.Fatal(err)
+	}
+	dependents, err = InstalledDependents(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dependents) != 0 {
+		t.Fatalf("unexpected dependents: %v", dependents)
+	}
+	dependents, err = InstalledDependents(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(dependents, []string{"b@2"}) {
+		t.Fatalf("unexpected dependents: %v", dependents)
+	}
+}
+
+func TestInstalledDependentsFeatures(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir, Dirs.InstalledDir = t.TempDir(), t.TempDir()
+	defer func() { Dirs = dirs }()
+	writeTestFeaturePorts(t)
+
+	ctx := NewBuildEnv()
+	install := func(nameVersion string) Port {
+		var port Port
+		if err := port.Init(ctx, nameVersion); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeStateFile(port.stateFile, "source", "", []string{"lib/" + port.Name + ".a"}); err != nil {
+			t.Fatal(err)
+		}
+		return port
+	}
+
+	// app@1 requires curl@8[ssl], but curl is installed with other features.
+	curl := install("curl@8[http2]")
+	install("app@1")
+
+	dependents, err := InstalledDependents(ctx, curl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(dependents, []string{"app@1"}) {
+		t.Fatalf("unexpected dependents: %v", dependents)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"buildenv/buildsystem"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// featureSeparator separates version and features in folder names like `curl@8.5.0~http2~ssl`,
+// it cannot appear in versions, since it's invalid in both git refs and semver.
+const featureSeparator = "~"
+
+// Feature is an optional part of port, it would be enabled when requested like `curl@8.5.0[ssl,http2]`.
+type Feature struct {
+	Description    string   `json:"description,omitempty"`
+	EnvVars        []string `json:"env_vars,omitempty"`
+	Patches        []string `json:"patches,omitempty"`
+	Options        []string `json:"options,omitempty"`
+	Depedencies    []string `json:"dependencies,omitempty"`
+	DevDepedencies []string `json:"dev_dependencies,omitempty"`
+}
+
+// apply appends the feature's options, dependencies and so on to build config.
+func (f Feature) apply(config *buildsystem.BuildConfig) {
+	config.EnvVars = append(config.EnvVars, f.EnvVars...)
+	config.Patches = append(config.Patches, f.Patches...)
+	config.Options = append(config.Options, f.Options...)
+	config.Depedencies = append(config.Depedencies, f.Depedencies...)
+	config.DevDepedencies = append(config.DevDepedencies, f.DevDepedencies...)
+}
+
+// splitFeatures splits `8.5.0[ssl,http2]` into version and sorted features.
+func splitFeatures(version string) (string, []string, error) {
+	index := strings.Index(version, "[")
+	if index < 0 {
+		if strings.Contains(version, "]") {
+			return "", nil, fmt.Errorf("features are invalid: %s", version)
+		}
+		return version, nil, nil
+	}
+	if !strings.HasSuffix(version, "]") {
+		return "", nil, fmt.Errorf("features are invalid: %s", version)
+	}
+
+	var features []string
+	for _, feature := range strings.Split(version[index+1:len(version)-1], ",") {
+		feature = strings.TrimSpace(feature)
+		if feature == "" {
+			continue
+		}
+		if !validFeatureName(feature) {
+			return "", nil, fmt.Errorf("feature name is invalid: %s", feature)
+		}
+		features = append(features, feature)
+	}
+
+	return strings.TrimSpace(version[:index]), mergeFeatures(features), nil
+}
+
+// validFeatureName checks if feature name can be used in `curl@8.5.0[ssl]` and folder names like `curl@8.5.0~ssl`.
+func validFeatureName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "[]@^+~ ,")
+}
+
+// mergeFeatures returns sorted features without duplicates.
+func mergeFeatures(features ...[]string) []string {
+	var merged []string
+	for _, items := range features {
+		merged = append(merged, items...)
+	}
+
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// formatFeatures formats features like `[http2,ssl]`.
+func formatFeatures(features []string) string {
+	if len(features) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(features, ",") + "]"
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitFeatures(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+		features []string
+		invalid  bool
+	}{
+		{version: "8.5.0", expected: "8.5.0"},
+		{version: "8.5.0[ssl]", expected: "8.5.0", features: []string{"ssl"}},
+		{version: "8.5.0[ssl, http2]", expected: "8.5.0", features: []string{"http2", "ssl"}},
+		{version: "8.5.0[ssl,ssl,]", expected: "8.5.0", features: []string{"ssl"}},
+		{version: ">=8.0[ssl]", expected: ">=8.0", features: []string{"ssl"}},
+		{version: "8.5.0[]", expected: "8.5.0"},
+		{version: "8.5.0[ssl", invalid: true},
+		{version: "8.5.0ssl]", invalid: true},
+		{version: "8.5.0[ss+l]", invalid: true},
+		{version: "8.5.0[ss l]", invalid: true},
+	}
+
+	for _, test := range tests {
+		version, features, err := splitFeatures(test.version)
+		if test.invalid {
+			if err == nil {
+				t.Fatalf("expected error of %s", test.version)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", test.version, err)
+		}
+		if version != test.expected || !slices.Equal(features, test.features) {
+			t.Fatalf("%s: expected %s%v, but got %s%v", test.version, test.expected, test.features, version, features)
+		}
+	}
+}
+
+func writeTestFeaturePorts(t *testing.T) {
+	writeTestPortFile(t, "curl@8", `{
+	"url": "https://example.com/curl.git",
+	"ref": "8",
+	"build_configs": [
+		{
+			"pattern": "*",
+			"build_tool": "cmake",
+			"options": ["-DBUILD_TESTING=OFF"]
+		}
+	],
+	"features": {
+		"ssl": {
+			"options": ["-DCURL_USE_OPENSSL=ON"],
+			"dependencies": ["openssl@3"]
+		},
+		"http2": {
+			"options": ["-DUSE_NGHTTP2=ON"],
+			"env_vars": ["NGHTTP2=1"]
+		}
+	}
+}`)
+	writeTestPort(t, "openssl@3", ``)
+	writeTestPort(t, "app@1", `"curl@8[ssl]"`)
+}
+
+func TestPortFeatures(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs = dirs }()
+	writeTestFeaturePorts(t)
+
+	tests := []struct {
+		nameVersion  string
+		folderName   string
+		options      []string
+		envVars      []string
+		dependencies []string
+		err          string
+	}{
+		{
+			nameVersion: "curl@8",
+			folderName:  "curl@8",
+			options:     []string{"-DBUILD_TESTING=OFF"},
+		},
+		{
+			nameVersion:  "curl@8[ssl]",
+			folderName:   "curl@8~ssl",
+			options:      []string{"-DBUILD_TESTING=OFF", "-DCURL_USE_OPENSSL=ON"},
+			dependencies: []string{"openssl@3"},
+		},
+		{
+			nameVersion:  "curl@8[ssl,http2]",
+			folderName:   "curl@8~http2~ssl",
+			options:      []string{"-DBUILD_TESTING=OFF", "-DUSE_NGHTTP2=ON", "-DCURL_USE_OPENSSL=ON"},
+			envVars:      []string{"NGHTTP2=1"},
+			dependencies: []string{"openssl@3"},
+		},
+		{
+			nameVersion: "curl@8[quic]",
+			err:         "feature quic is not defined in curl@8",
+		},
+	}
+
+	for _, test := range tests {
+		var port Port
+		err := port.Init(NewBuildEnv(), test.nameVersion)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("%s: expected error %q, but got %v", test.nameVersion, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", test.nameVersion, err)
+		}
+
+		config := port.BuildConfigs[0]
+		if port.folderName() != test.folderName {
+			t.Fatalf("%s: expected folder name %s, but got %s", test.nameVersion, test.folderName, port.folderName())
+		}
+		if !slices.Equal(config.Options, test.options) {
+			t.Fatalf("%s: expected options %v, but got %v", test.nameVersion, test.options, config.Options)
+		}
+		if !slices.Equal(config.EnvVars, test.envVars) {
+			t.Fatalf("%s: expected env vars %v, but got %v", test.nameVersion, test.envVars, config.EnvVars)
+		}
+		if !slices.Equal(config.Depedencies, test.dependencies) {
+			t.Fatalf("%s: expected dependencies %v, but got %v", test.nameVersion, test.dependencies, config.Depedencies)
+		}
+	}
+}
+
+func TestResolveFeatures(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs = dirs }()
+	writeTestFeaturePorts(t)
+
+	tests := []struct {
+		ports    []string
+		features []string
+		versions []string
+	}{
+		// No feature is enabled unless it's requested.
+		{ports: []string{"curl@8"}, versions: []string{"curl"}},
+		// Feature requested by sub dependency also brings its dependencies.
+		{ports: []string{"app@1"}, features: []string{"ssl"}, versions: []string{"app", "curl", "openssl"}},
+		// Features requested by different parents are enabled together.
+		{ports: []string{"app@1", "curl@8[http2]"}, features: []string{"http2", "ssl"}, versions: []string{"app", "curl", "openssl"}},
+	}
+
+	for _, test := range tests {
+		ctx := NewBuildEnv()
+		ctx.project = Project{
+			Name:             "test_project",
+			Ports:            test.ports,
+			ctx:              ctx,
+			resolvedVersions: make(map[string]string),
+			resolvedFeatures: make(map[string][]string),
+			lockedPorts:      make(map[string]LockedPort),
+		}
+		if err := ctx.project.resolvePorts(); err != nil {
+			t.Fatalf("%v: %s", test.ports, err)
+		}
+
+		if features := ctx.project.resolvedFeatures["curl"]; !slices.Equal(features, test.features) {
+			t.Fatalf("%v: expected features of curl %v, but got %v", test.ports, test.features, features)
+		}
+		var versions []string
+		for key := range ctx.project.resolvedVersions {
+			versions = append(versions, key)
+		}
+		slices.Sort(versions)
+		if !slices.Equal(versions, test.versions) {
+			t.Fatalf("%v: expected resolved ports %v, but got %v", test.ports, test.versions, versions)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileOwners maps full path of every installed file to the ports that installed it.
+type FileOwners map[string][]StateFile
+
+// ReadFileOwners builds file owners from all state files.
+func ReadFileOwners() (FileOwners, error) {
+	states, err := ListStateFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(FileOwners)
+	for _, state := range states {
+		for _, file := range state.Files {
+			path := state.InstalledPath(file)
+			owners[path] = append(owners[path], state)
+		}
+	}
+	return owners, nil
+}
+
+// Others returns owners of the file except the port, it's compared by full name
+// or only name when version and features are ignored.
+func (f FileOwners) Others(path, name string) []StateFile {
+	var others []StateFile
+	for _, state := range f[path] {
+		if state.Name != name && state.FullName() != name {
+			others = append(others, state)
+		}
+	}
+	return others
+}
+
+// validateAllowOverwrite checks patterns of allow_overwrite.
+func validateAllowOverwrite(patterns []string) error {
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("pattern is empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q is invalid pattern: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// allowOverwrite checks if file can be overwritten by another port,
+// file is relative to its install prefix, like `include/config.h`.
+func (p Project) allowOverwrite(file string) bool {
+	file = filepath.ToSlash(file)
+	for _, pattern := range p.AllowOverwrite {
+		if matched, _ := path.Match(pattern, file); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFileConflicts returns error when files to install are already installed by other ports.
+func (p Port) checkFileConflicts(files []string) error {
+	owners, err := ReadFileOwners()
+	if err != nil {
+		return err
+	}
+
+	state := StateFile{Dev: p.AsDev}
+	var conflicts []string
+	for _, file := range files {
+		// Files of non-dev port are prefixed with folder like `x86_64-linux^demo^Release`.
+		relPath := file
+		if !p.AsDev {
+			_, relPath, _ = strings.Cut(filepath.ToSlash(file), "/")
+		}
+		if p.ctx.Project().allowOverwrite(relPath) {
+			continue
+		}
+		// Installing another version or features of the same port replaces its files.
+		for _, owner := range owners.Others(state.InstalledPath(file), p.Name) {
+			conflicts = append(conflicts, fmt.Sprintf("%s (installed by %s)", file, owner.FullName()))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("files of %s conflict with installed ports:\n    - %s\n"+
+			"remove the installed ports first, or add the files to allow_overwrite of project %s",
+			p.FullName(), strings.Join(conflicts, "\n    - "), p.ctx.Project().Name)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckFileConflicts(t *testing.T) {
+	portsDir, installedDir := Dirs.PortsDir, Dirs.InstalledDir
+	Dirs.PortsDir, Dirs.InstalledDir = t.TempDir(), t.TempDir()
+	defer func() { Dirs.PortsDir, Dirs.InstalledDir = portsDir, installedDir }()
+
+	writeTestPort(t, "zlib@1.3.1", ``)
+	writeTestPort(t, "zlib@1.3.0", ``)
+	writeTestPort(t, "minizip@1.3.1", ``)
+
+	ctx := NewBuildEnv()
+	newPort := func(nameVersion string) Port {
+		var port Port
+		if err := port.Init(ctx, nameVersion); err != nil {
+			t.Fatal(err)
+		}
+		return port
+	}
+
+	zlib := newPort("zlib@1.3.1")
+	zlibFiles := []string{"x86_64-linux^demo^Release/include/zconf.h", "x86_64-linux^demo^Release/lib/pkgconfig/zlib.pc"}
+	if err := writeStateFile(zlib.stateFile, "source", "", zlibFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	// State file with invalid name is skipped, it doesn't fail every install.
+	if err := writeStateFile(filepath.Join(filepath.Dir(zlib.stateFile), "broken.list"), "source", "", zlibFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another version of the same port replaces its files.
+	if err := newPort("zlib@1.3.0").checkFileConflicts(zlibFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	minizip := newPort("minizip@1.3.1")
+	minizipFiles := append([]string{"x86_64-linux^demo^Release/include/unzip.h"}, zlibFiles...)
+	err := minizip.checkFileConflicts(minizipFiles)
+	if err == nil {
+		t.Fatal("expected conflict error, but got nil")
+	}
+	for _, file := range zlibFiles {
+		if !strings.Contains(err.Error(), file+" (installed by zlib@1.3.1)") {
+			t.Fatalf("expected conflict of %s, but got: %s", file, err)
+		}
+	}
+	if strings.Contains(err.Error(), "unzip.h") {
+		t.Fatalf("unexpected conflict of unzip.h: %s", err)
+	}
+
+	// Conflicts are ignored when files are allowed to overwrite.
+	ctx.project.AllowOverwrite = []string{"include/zconf.h", "lib/pkgconfig/*.pc"}
+	if err := minizip.checkFileConflicts(minizipFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	// Files shared by two ports are kept when removing one of them.
+	if err := writeStateFile(minizip.stateFile, "source", "", minizipFiles); err != nil {
+		t.Fatal(err)
+	}
+	owners, err := ReadFileOwners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := StateFile{}.InstalledPath(zlibFiles[0])
+	if others := owners.Others(path, "zlib@1.3.1"); len(others) != 1 || others[0].Name != "minizip" {
+		t.Fatalf("unexpected other owners: %v", others)
+	}
+	unzip := StateFile{}.InstalledPath(minizipFiles[0])
+	if others := owners.Others(unzip, "minizip@1.3.1"); len(others) != 0 {
+		t.Fatalf("unexpected other owners: %v", others)
+	}
+}
+
+func TestValidateAllowOverwrite(t *testing.T) {
+	if err := validateAllowOverwrite([]string{"include/config.h", "lib/pkgconfig/*.pc"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"", "include/[config.h"} {
+		if err := validateAllowOverwrite([]string{pattern}); err == nil {
+			t.Fatalf("expected error of %q, but got nil", pattern)
+		}
+	}
+}
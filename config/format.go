@@ -17,6 +17,10 @@ func PrintSuccess(format string, args ...interface{}) {
 	color.Printf(color.Magenta, "\n[✔] ======== %s ========\n\n", fmt.Sprintf(format, args...))
 }
 
+func PrintWarning(format string, args ...interface{}) {
+	color.Printf(color.Yellow, "[⚠] %s.\n", fmt.Sprintf(format, args...))
+}
+
 func PrintError(err error, format string, args ...interface{}) {
 	color.Printf(color.Red, "\n[✘] %s\n[☛] %s.\n\n", fmt.Sprintf(format, args...), err)
 }
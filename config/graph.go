@@ -0,0 +1,272 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Graph is the dependency graph of ports for current platform,
+// dev ports and none-dev ports are different nodes since they are built for different hosts.
+type Graph struct {
+	Roots []*GraphNode
+	Nodes []*GraphNode // All nodes in the order they are discovered.
+
+	nodes map[string]*GraphNode
+}
+
+type GraphNode struct {
+	Port         Port
+	Dev          bool
+	Pattern      string       // Pattern of matched build_config.
+	BuildTool    string       // Build tool of matched build_config.
+	Dependencies []*GraphNode // Dependencies and dev_dependencies of matched build_config.
+}
+
+// Label returns the name of node, like `zlib@1.3.1` or `cmake@3.30.5 (dev)`.
+func (g GraphNode) Label() string {
+	if g.Dev {
+		return g.Port.FullName() + " (dev)"
+	}
+	return g.Port.FullName()
+}
+
+func (g GraphNode) key() string {
+	return resolveKey(g.Port.Name, g.Dev) + "@" + g.Port.folderName()
+}
+
+// BuildGraph walks through `dependencies` and `dev_dependencies` of ports
+// that matched current platform, versions are resolved as what they would be installed.
+func BuildGraph(ctx Context, nameVersions []string, asDev bool) (*Graph, error) {
+	graph := Graph{nodes: make(map[string]*GraphNode)}
+
+	for _, nameVersion := range nameVersions {
+		node, err := graph.addNode(ctx, nameVersion, asDev, false)
+		if err != nil {
+			return nil, err
+		}
+		graph.Roots = append(graph.Roots, node)
+	}
+
+	return &graph, nil
+}
+
+func (g *Graph) addNode(ctx Context, nameVersion string, asDev, asSubDep bool) (*GraphNode, error) {
+	var port Port
+	port.AsDev = asDev
+	port.AsSubDep = asSubDep
+	if err := port.Init(ctx, nameVersion); err != nil {
+		return nil, err
+	}
+
+	// Reuse the node if it's already added.
+	node := &GraphNode{Port: port, Dev: asDev}
+	if existing, ok := g.nodes[node.key()]; ok {
+		return existing, nil
+	}
+	g.nodes[node.key()] = node
+	g.Nodes = append(g.Nodes, node)
+
+	// Ports without build configs have no dependencies.
+	if len(port.BuildConfigs) == 0 {
+		return node, nil
+	}
+	matchedConfig, err := port.MatchedConfig()
+	if err != nil {
+		return nil, err
+	}
+	node.Pattern = matchedConfig.Pattern.String()
+	node.BuildTool = matchedConfig.BuildTool
+
+	for _, dependency := range matchedConfig.DevDepedencies {
+		// Dev port may depend on itself in dev_dependencies, it's always installed already in host.
+		if name, _, err := splitNameVersion(dependency); err == nil && asDev && name == port.Name {
+			continue
+		}
+
+		child, err := g.addNode(ctx, dependency, true, true)
+		if err != nil {
+			return nil, err
+		}
+		node.Dependencies = append(node.Dependencies, child)
+	}
+	for _, dependency := range matchedConfig.Depedencies {
+		child, err := g.addNode(ctx, dependency, asDev, true)
+		if err != nil {
+			return nil, err
+		}
+		node.Dependencies = append(node.Dependencies, child)
+	}
+
+	return node, nil
+}
+
+// DetectCycle returns error with the full cycle path, like `a@1 -> b@2 -> a@1`.
+func (g Graph) DetectCycle() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	states := make(map[*GraphNode]int)
+	var path []*GraphNode
+
+	var visit func(node *GraphNode) error
+	visit = func(node *GraphNode) error {
+		states[node] = visiting
+		path = append(path, node)
+
+		for _, child := range node.Dependencies {
+			switch states[child] {
+			case visiting:
+				// Cut the path from where the cycle begins.
+				var labels []string
+				for index := len(path) - 1; index >= 0; index-- {
+					if path[index] == child {
+						for _, item := range path[index:] {
+							labels = append(labels, item.Label())
+						}
+						break
+					}
+				}
+				labels = append(labels, child.Label())
+				return fmt.Errorf("detected circular dependency: %s", strings.Join(labels, " -> "))
+
+			case unvisited:
+				if err := visit(child); err != nil {
+					return err
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[node] = visited
+		return nil
+	}
+
+	for _, node := range g.Nodes {
+		if states[node] == unvisited {
+			if err := visit(node); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Text prints graph as a tree, ports already printed are marked with `(*)` and not expanded again.
+func (g Graph) Text() string {
+	var builder strings.Builder
+	printed := make(map[*GraphNode]bool)
+
+	var walk func(node *GraphNode, prefix, indent string)
+	walk = func(node *GraphNode, prefix, indent string) {
+		if printed[node] && len(node.Dependencies) > 0 {
+			builder.WriteString(prefix + node.Label() + " (*)\n")
+			return
+		}
+		printed[node] = true
+		builder.WriteString(prefix + node.Label() + "  " + node.summary(", ") + "\n")
+
+		for index, child := range node.Dependencies {
+			if index == len(node.Dependencies)-1 {
+				walk(child, indent+"└── ", indent+"    ")
+			} else {
+				walk(child, indent+"├── ", indent+"│   ")
+			}
+		}
+	}
+
+	for _, root := range g.Roots {
+		walk(root, "", "")
+	}
+
+	return builder.String()
+}
+
+// Dot prints graph in Graphviz DOT format, dev ports are drawn with dashed border.
+func (g Graph) Dot(name string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("digraph %q {\n", name))
+	builder.WriteString("    node [shape=box];\n")
+
+	for _, node := range g.Nodes {
+		style := "solid"
+		if node.Dev {
+			style = "dashed"
+		}
+		label := node.Label() + "\n" + node.summary("\n")
+		builder.WriteString(fmt.Sprintf("    %q [label=%q, style=%s];\n", node.Label(), label, style))
+	}
+	for _, node := range g.Nodes {
+		for _, child := range node.Dependencies {
+			builder.WriteString(fmt.Sprintf("    %q -> %q;\n", node.Label(), child.Label()))
+		}
+	}
+
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+// JSON prints graph as json, nodes refer to their dependencies by id.
+func (g Graph) JSON() ([]byte, error) {
+	type jsonNode struct {
+		ID           string   `json:"id"`
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		Features     []string `json:"features,omitempty"`
+		Dev          bool     `json:"dev"`
+		Pattern      string   `json:"pattern"`
+		BuildTool    string   `json:"build_tool"`
+		Installed    bool     `json:"installed"`
+		Dependencies []string `json:"dependencies"`
+	}
+
+	var graph struct {
+		Roots []string   `json:"roots"`
+		Nodes []jsonNode `json:"nodes"`
+	}
+
+	for _, root := range g.Roots {
+		graph.Roots = append(graph.Roots, root.Label())
+	}
+	for _, node := range g.Nodes {
+		dependencies := []string{}
+		for _, child := range node.Dependencies {
+			dependencies = append(dependencies, child.Label())
+		}
+
+		graph.Nodes = append(graph.Nodes, jsonNode{
+			ID:           node.Label(),
+			Name:         node.Port.Name,
+			Version:      node.Port.Version,
+			Features:     node.Port.SelectedFeatures,
+			Dev:          node.Dev,
+			Pattern:      node.Pattern,
+			BuildTool:    node.BuildTool,
+			Installed:    node.Port.Installed(),
+			Dependencies: dependencies,
+		})
+	}
+
+	return json.MarshalIndent(graph, "", "    ")
+}
+
+// summary returns matched pattern, build tool and install state of node.
+func (g GraphNode) summary(sep string) string {
+	var items []string
+	if g.Pattern != "" {
+		items = append(items, "pattern: "+g.Pattern)
+	}
+	if g.BuildTool != "" {
+		items = append(items, "build_tool: "+g.BuildTool)
+	}
+	if g.Port.Installed() {
+		items = append(items, "installed")
+	} else {
+		items = append(items, "not installed")
+	}
+	return strings.Join(items, sep)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func writeTestPort(t *testing.T, nameVersion, dependencies string) {
+	name, version, err := splitNameVersion(nameVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := `{
+	"url": "https://example.com/` + name + `.git",
+	"ref": "` + version + `",
+	"build_configs": [
+		{
+			"pattern": "*",
+			"build_tool": "cmake",
+			"dependencies": [` + dependencies + `]
+		}
+	]
+}`
+	writeTestPortFile(t, nameVersion, content)
+}
+
+func TestDetectCycle(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPort(t, "a@1", `"b@2"`)
+	writeTestPort(t, "b@2", `"c@3"`)
+	writeTestPort(t, "c@3", `"a@1"`)
+
+	graph, err := BuildGraph(NewBuildEnv(), []string{"a@1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = graph.DetectCycle()
+	if err == nil {
+		t.Fatal("expected circular dependency error")
+	}
+	if expected := "detected circular dependency: a@1 -> b@2 -> c@3 -> a@1"; err.Error() != expected {
+		t.Fatalf("expected %q but got %q", expected, err.Error())
+	}
+}
+
+func TestDetectNoCycle(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	// Port name with the same prefix is not a circular dependency.
+	writeTestPort(t, "gflags@v2.2.2", `"gflags-extra@1.0.0"`)
+	writeTestPort(t, "gflags-extra@1.0.0", ``)
+
+	graph, err := BuildGraph(NewBuildEnv(), []string{"gflags@v2.2.2"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.DetectCycle(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGraphText(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPort(t, "a@1", `"b@2", "c@3"`)
+	writeTestPort(t, "b@2", `"c@3"`)
+	writeTestPort(t, "c@3", ``)
+
+	graph, err := BuildGraph(NewBuildEnv(), []string{"a@1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "a@1  pattern: *, build_tool: cmake, not installed\n" +
+		"├── b@2  pattern: *, build_tool: cmake, not installed\n" +
+		"│   └── c@3  pattern: *, build_tool: cmake, not installed\n" +
+		"└── c@3  pattern: *, build_tool: cmake, not installed\n"
+	if text := graph.Text(); text != expected {
+		t.Fatalf("expected:\n%s\nbut got:\n%s", expected, text)
+	}
+
+	bytes, err := graph.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bytes), `"dependencies": [
+                "b@2",
+                "c@3"
+            ]`) {
+		t.Fatalf("unexpected json:\n%s", bytes)
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Phases of install journal.
+const (
+	journalStaging    = "staging"    // Files are being copied into staging dir, installed dir is not touched yet.
+	journalCommitting = "committing" // Staged files are being moved into installed dir.
+	journalFailed     = "failed"     // Committing failed, moved files should be rolled back.
+)
+
+// renameFile is os.Rename, it's replaced in tests to simulate failures.
+var renameFile = os.Rename
+
+// installJournal records an install in progress, so that it can be rolled back or resumed when interrupted.
+// It's saved as `installed/buildenv/journal/<state file name>.json`, and files are staged in
+// `installed/buildenv/staging/<state file name>`, which is in the same filesystem with installed dir.
+// Installed files overwritten by committing are kept in `installed/buildenv/backup/<state file name>`
+// until the install is done, so that they can be restored when committing failed.
+type installJournal struct {
+	Phase        string   `json:"phase"`
+	From         string   `json:"from"`            // Where port was installed from.
+	Flags        string   `json:"flags,omitempty"` // Hash of project flags that port was built with.
+	InstalledDir string   `json:"installed_dir"`   // Dir that files would be moved to.
+	StateFile    string   `json:"state_file"`      // State file that would be written at last.
+	StateFiles   []string `json:"state_files"`     // Files recorded in state file.
+	Files        []string `json:"files"`           // Files relative to installed dir.
+
+	// Internal fields.
+	path       string            `json:"-"`
+	stagingDir string            `json:"-"`
+	backupDir  string            `json:"-"`
+	sources    map[string]string `json:"-"` // Package file of every file to install.
+}
+
+func (p Port) newInstallJournal(from string) *installJournal {
+	id := strings.TrimSuffix(filepath.Base(p.stateFile), ".list")
+	return &installJournal{
+		From:         from,
+		InstalledDir: p.installedDir,
+		StateFile:    p.stateFile,
+		path:         filepath.Join(Dirs.InstalledDir, "buildenv", "journal", id+".json"),
+		stagingDir:   filepath.Join(Dirs.InstalledDir, "buildenv", "staging", id),
+		backupDir:    filepath.Join(Dirs.InstalledDir, "buildenv", "backup", id),
+		sources:      make(map[string]string),
+	}
+}
+
+// add adds a file to install, file added later would override the former one.
+func (i *installJournal) add(src, file string) {
+	if _, ok := i.sources[file]; !ok {
+		i.Files = append(i.Files, file)
+	}
+	i.sources[file] = src
+}
+
+// stage copies all files into staging dir, installed dir is untouched if it fails.
+func (i *installJournal) stage() error {
+	if err := os.RemoveAll(i.stagingDir); err != nil {
+		return err
+	}
+
+	i.Phase = journalStaging
+	if err := i.save(); err != nil {
+		return err
+	}
+
+	for _, file := range i.Files {
+		dest := filepath.Join(i.stagingDir, file)
+		if err := os.MkdirAll(filepath.Dir(dest), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		if err := fileio.CopyFile(i.sources[file], dest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commit moves staged files into installed dir and writes state file, it can be called again to resume.
+// When it failed, files moved are rolled back and journal is marked as failed, so it won't be resumed again.
+func (i *installJournal) commit() error {
+	i.Phase = journalCommitting
+	if err := i.save(); err != nil {
+		return err
+	}
+
+	for _, file := range i.Files {
+		if err := i.move(file); err != nil {
+			i.Phase = journalFailed
+			if err := i.save(); err != nil {
+				return err
+			}
+			return errors.Join(fmt.Errorf("install %s: %w", file, err), i.rollback())
+		}
+	}
+
+	if err := writeStateFile(i.StateFile, i.From, i.Flags, i.StateFiles); err != nil {
+		return err
+	}
+	return i.clean()
+}
+
+// move moves staged file into installed dir, the installed one is backed up before overwritten.
+func (i *installJournal) move(file string) error {
+	// File has been moved before interrupted.
+	staged := filepath.Join(i.stagingDir, file)
+	if _, err := os.Lstat(staged); os.IsNotExist(err) {
+		return nil
+	}
+
+	dest := filepath.Join(i.InstalledDir, file)
+	if _, err := os.Lstat(dest); err == nil {
+		backup := filepath.Join(i.backupDir, file)
+		if err := os.MkdirAll(filepath.Dir(backup), os.ModeDir|os.ModePerm); err != nil {
+			return err
+		}
+		if err := renameFile(dest, backup); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+	return renameFile(staged, dest)
+}
+
+// rollback removes files moved into installed dir and restores the overwritten ones,
+// installed dir is untouched in staging phase.
+func (i *installJournal) rollback() error {
+	if i.Phase == journalFailed {
+		for _, file := range i.Files {
+			dest := filepath.Join(i.InstalledDir, file)
+			backup := filepath.Join(i.backupDir, file)
+
+			// File is moved when it's not in staging dir any more.
+			if _, err := os.Lstat(filepath.Join(i.stagingDir, file)); os.IsNotExist(err) {
+				if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+			if _, err := os.Lstat(backup); err == nil {
+				if err := os.Rename(backup, dest); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return i.clean()
+}
+
+func (i *installJournal) clean() error {
+	if err := os.RemoveAll(i.stagingDir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(i.backupDir); err != nil {
+		return err
+	}
+	if err := os.Remove(i.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// save writes journal to a temporary file then renames it, so journal is never half written.
+func (i *installJournal) save() error {
+	if err := os.MkdirAll(filepath.Dir(i.path), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+
+	bytes, err := json.MarshalIndent(i, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(i.path+".tmp", bytes, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(i.path+".tmp", i.path)
+}
+
+// lockInstall locks `installed/buildenv/install.lock`, since ports are installed by child processes concurrently.
+func lockInstall() (unlock func() error, err error) {
+	return fileio.LockFile(filepath.Join(Dirs.InstalledDir, "buildenv", "install.lock"))
+}
+
+// recoverInstall rolls back the last install of port interrupted during staging or failed during committing,
+// or resumes it when interrupted during committing.
+func (p Port) recoverInstall() error {
+	journal := p.newInstallJournal("")
+	bytes, err := os.ReadFile(journal.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(bytes, journal); err != nil {
+		return fmt.Errorf("read journal %s: %w", journal.path, err)
+	}
+
+	unlock, err := lockInstall()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	switch journal.Phase {
+	case journalCommitting:
+		return journal.commit()
+	default:
+		return journal.rollback()
+	}
+}
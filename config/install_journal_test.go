@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallJournal(t *testing.T) {
+	installedDir := Dirs.InstalledDir
+	Dirs.InstalledDir = t.TempDir()
+	defer func() { Dirs.InstalledDir = installedDir }()
+
+	packageDir := t.TempDir()
+	files := []string{"include/zlib.h", "lib/libz.a"}
+	for _, file := range files {
+		path := filepath.Join(packageDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var port Port
+	port.installedDir = filepath.Join(Dirs.InstalledDir, "x86_64-linux^demo^Release")
+	port.stateFile = filepath.Join(Dirs.InstalledDir, "buildenv", "info", "zlib@1.3.1^x86_64-linux^demo^Release.list")
+
+	newJournal := func() *installJournal {
+		journal := port.newInstallJournal("source")
+		for _, file := range files {
+			journal.add(filepath.Join(packageDir, file), file)
+		}
+		journal.StateFiles = []string{"x86_64-linux^demo^Release/include/zlib.h", "x86_64-linux^demo^Release/lib/libz.a"}
+		return journal
+	}
+
+	// Interrupted while staging: installed dir is untouched and staged files are rolled back.
+	journal := newJournal()
+	if err := journal.stage(); err != nil {
+		t.Fatal(err)
+	}
+	if err := port.recoverInstall(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(journal.stagingDir); !os.IsNotExist(err) {
+		t.Fatalf("expected staging dir to be removed, but got %v", err)
+	}
+	if _, err := os.Stat(port.installedDir); !os.IsNotExist(err) {
+		t.Fatalf("expected installed dir to be untouched, but got %v", err)
+	}
+
+	// Interrupted while committing: rest of staged files are moved and state file is written.
+	journal = newJournal()
+	if err := journal.stage(); err != nil {
+		t.Fatal(err)
+	}
+	journal.Phase = journalCommitting
+	if err := journal.save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(port.installedDir, "include"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(journal.stagingDir, files[0]), filepath.Join(port.installedDir, files[0])); err != nil {
+		t.Fatal(err)
+	}
+	if err := port.recoverInstall(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := ReadStateFile(port.stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := state.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.From != "source" || len(state.Files) != 2 || len(issues) != 0 {
+		t.Fatalf("unexpected state of resumed install: %+v, issues: %v", state, issues)
+	}
+	if _, err := os.Stat(journal.path); !os.IsNotExist(err) {
+		t.Fatalf("expected journal to be removed, but got %v", err)
+	}
+}
+
+func TestInstallJournalCommitFailed(t *testing.T) {
+	installedDir := Dirs.InstalledDir
+	Dirs.InstalledDir = t.TempDir()
+	defer func() { Dirs.InstalledDir = installedDir }()
+
+	var port Port
+	port.installedDir = filepath.Join(Dirs.InstalledDir, "x86_64-linux^demo^Release")
+	port.stateFile = filepath.Join(Dirs.InstalledDir, "buildenv", "info", "zlib@1.3.1^x86_64-linux^demo^Release.list")
+
+	// Files of last installed version would be overwritten.
+	packageDir := t.TempDir()
+	files := []string{"include/zlib.h", "lib/libz.a"}
+	for _, file := range files {
+		for dir, content := range map[string]string{packageDir: "new", port.installedDir: "old"} {
+			path := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	newJournal := func() *installJournal {
+		journal := port.newInstallJournal("source")
+		for _, file := range files {
+			journal.add(filepath.Join(packageDir, file), file)
+		}
+		if err := journal.stage(); err != nil {
+			t.Fatal(err)
+		}
+		return journal
+	}
+	checkRolledBack := func(journal *installJournal) {
+		for _, file := range files {
+			bytes, err := os.ReadFile(filepath.Join(port.installedDir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bytes) != "old" {
+				t.Fatalf("expected %s to be restored, but got %q", file, bytes)
+			}
+		}
+		for _, path := range []string{journal.path, journal.stagingDir, journal.backupDir, port.stateFile} {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("expected %s to be removed, but got %v", path, err)
+			}
+		}
+	}
+
+	// Moving the last file fails, files moved before are rolled back.
+	rename := renameFile
+	defer func() { renameFile = rename }()
+	renameFile = func(oldPath, newPath string) error {
+		if strings.HasSuffix(newPath, filepath.Join(port.installedDir, files[1])) {
+			return fmt.Errorf("disk is full")
+		}
+		return rename(oldPath, newPath)
+	}
+
+	journal := newJournal()
+	if err := journal.commit(); err == nil || !strings.Contains(err.Error(), "disk is full") {
+		t.Fatalf("expected commit error, but got %v", err)
+	}
+	checkRolledBack(journal)
+
+	// Journal failed but not rolled back is rolled back when recovered, instead of resumed.
+	renameFile = rename
+	journal = newJournal()
+	if err := journal.move(files[0]); err != nil {
+		t.Fatal(err)
+	}
+	journal.Phase = journalFailed
+	if err := journal.save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := port.recoverInstall(); err != nil {
+		t.Fatal(err)
+	}
+	checkRolledBack(journal)
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"buildenv/pkg/cmd"
+	"buildenv/pkg/fileio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Lockfile records the fully resolved port graph of every platform, project and build type,
+// then later runs would reproduce exactly the same graph.
+type Lockfile struct {
+	Graphs []LockGraph `json:"graphs"`
+}
+
+type LockGraph struct {
+	Platform  string       `json:"platform"`
+	Project   string       `json:"project"`
+	BuildType string       `json:"build_type"`
+	Ports     []LockedPort `json:"ports"`
+}
+
+type LockedPort struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Features []string `json:"features,omitempty"`
+	Dev      bool     `json:"dev,omitempty"`
+	Url      string   `json:"url"`
+	Commit   string   `json:"commit,omitempty"` // Commit sha of git repo.
+	Sha256   string   `json:"sha256,omitempty"` // Sha256 of downloaded archive.
+	Pattern  string   `json:"pattern"`          // Pattern of matched build_config.
+}
+
+func (l LockedPort) key() string {
+	return resolveKey(l.Name, l.Dev)
+}
+
+func lockfilePath() string {
+	return filepath.Join(Dirs.WorkspaceDir, "buildenv.lock")
+}
+
+func readLockfile() (*Lockfile, error) {
+	var lockfile Lockfile
+	if !fileio.PathExists(lockfilePath()) {
+		return &lockfile, nil
+	}
+
+	bytes, err := os.ReadFile(lockfilePath())
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bytes, &lockfile); err != nil {
+		return nil, fmt.Errorf("read buildenv.lock error: %w", err)
+	}
+
+	return &lockfile, nil
+}
+
+func (l Lockfile) write() error {
+	bytes, err := json.MarshalIndent(l, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(lockfilePath(), bytes, os.ModePerm)
+}
+
+func (l Lockfile) findGraph(platform, project, buildType string) *LockGraph {
+	for index, graph := range l.Graphs {
+		if graph.Platform == platform && graph.Project == project && graph.BuildType == buildType {
+			return &l.Graphs[index]
+		}
+	}
+
+	return nil
+}
+
+// setGraph adds or replaces the graph with the same platform, project and build type.
+func (l *Lockfile) setGraph(graph LockGraph) {
+	if existing := l.findGraph(graph.Platform, graph.Project, graph.BuildType); existing != nil {
+		*existing = graph
+		return
+	}
+
+	l.Graphs = append(l.Graphs, graph)
+}
+
+// loadLock reads the locked graph of current platform, project and build type,
+// ports would be pinned to the locked versions when resolving.
+func (p *Project) loadLock(updateLock bool) error {
+	clear(p.lockedPorts)
+	if updateLock {
+		return nil
+	}
+
+	lockfile, err := readLockfile()
+	if err != nil {
+		return err
+	}
+
+	graph := lockfile.findGraph(p.ctx.Platform().Name, p.Name, p.ctx.BuildType())
+	if graph == nil {
+		return nil
+	}
+	for _, port := range graph.Ports {
+		p.lockedPorts[port.key()] = port
+	}
+
+	return nil
+}
+
+// lockedVersion returns the version pinned by buildenv.lock.
+func (p Project) lockedVersion(key string) (string, bool) {
+	if port, ok := p.lockedPorts[key]; ok {
+		return port.Version, true
+	}
+
+	return "", false
+}
+
+// checkLock checks if ports locked before are resolved the same as the locked ones,
+// ports that are not locked yet would be added when writing lock.
+func (p Project) checkLock() error {
+	if len(p.lockedPorts) == 0 {
+		return nil
+	}
+
+	graph, err := p.lockGraph()
+	if err != nil {
+		return err
+	}
+
+	var summaries []string
+	for _, port := range graph {
+		locked, ok := p.lockedPorts[port.key()]
+		switch {
+		case !ok:
+			continue
+		case locked.Url != port.Url:
+			summaries = append(summaries, fmt.Sprintf("    - url of %s@%s is changed from %s to %s", port.Name, port.Version, locked.Url, port.Url))
+		case !slices.Equal(locked.Features, port.Features):
+			summaries = append(summaries, fmt.Sprintf("    - features of %s@%s are changed from %v to %v", port.Name, port.Version, locked.Features, port.Features))
+		case locked.Pattern != port.Pattern:
+			summaries = append(summaries, fmt.Sprintf("    - matched pattern of %s@%s is changed from %q to %q", port.Name, port.Version, locked.Pattern, port.Pattern))
+		}
+	}
+	for key, locked := range p.lockedPorts {
+		if !slices.ContainsFunc(graph, func(port LockedPort) bool { return port.key() == key }) {
+			summaries = append(summaries, fmt.Sprintf("    - %s@%s is locked but no longer required", locked.Name, locked.Version))
+		}
+	}
+
+	if len(summaries) > 0 {
+		slices.Sort(summaries)
+		return fmt.Errorf("buildenv.lock is out of date, please run with --update-lock:\n%s", strings.Join(summaries, "\n"))
+	}
+
+	return nil
+}
+
+// lockGraph collects all resolved ports as locked ports,
+// commits and checksums recorded before would be kept.
+func (p Project) lockGraph() ([]LockedPort, error) {
+	var graph []LockedPort
+	for key, version := range p.resolvedVersions {
+		name := strings.TrimSuffix(key, "^dev")
+
+		var port Port
+		port.AsDev = strings.HasSuffix(key, "^dev")
+		port.AsSubDep = true
+		if err := port.Init(p.ctx, name+"@"+version); err != nil {
+			return nil, err
+		}
+
+		lockedPort := LockedPort{
+			Name:     port.Name,
+			Version:  port.Version,
+			Features: port.SelectedFeatures,
+			Dev:      port.AsDev,
+			Url:      port.Url,
+		}
+		if len(port.BuildConfigs) > 0 {
+			matchedConfig, err := port.MatchedConfig()
+			if err != nil {
+				return nil, err
+			}
+			lockedPort.Pattern = matchedConfig.Pattern.String()
+		}
+
+		// Keep commit and checksum recorded before.
+		if locked, ok := p.lockedPorts[key]; ok && locked.Version == version {
+			lockedPort.Commit = locked.Commit
+			lockedPort.Sha256 = locked.Sha256
+		}
+
+		graph = append(graph, lockedPort)
+	}
+
+	slices.SortFunc(graph, func(a, b LockedPort) int {
+		return strings.Compare(a.key(), b.key())
+	})
+	return graph, nil
+}
+
+// WriteLock records resolved ports into buildenv.lock,
+// commits and checksums would be filled once sources are cloned or downloaded.
+func (p Project) WriteLock() error {
+	graph, err := p.lockGraph()
+	if err != nil {
+		return err
+	}
+
+	for index, port := range graph {
+		if strings.HasSuffix(port.Url, ".git") {
+			sourceDir := filepath.Join(Dirs.WorkspaceDir, "buildtrees", portFolderName(port.Name, port.Version, port.Features), "src")
+			if port.Commit == "" && fileio.PathExists(filepath.Join(sourceDir, ".git")) {
+				commit, err := cmd.RepoCommit(sourceDir)
+				if err != nil {
+					return err
+				}
+				graph[index].Commit = commit
+			}
+		} else {
+			archivePath := filepath.Join(Dirs.DownloadedDir, fileio.ArchiveName(port.Url))
+			if port.Sha256 == "" && fileio.PathExists(archivePath) {
+				checksum, err := fileio.FileSha256(archivePath)
+				if err != nil {
+					return err
+				}
+				graph[index].Sha256 = checksum
+			}
+		}
+
+		// Later installed ports in this run should also be pinned.
+		p.lockedPorts[port.key()] = graph[index]
+	}
+
+	lockfile, err := readLockfile()
+	if err != nil {
+		return err
+	}
+	lockfile.setGraph(LockGraph{
+		Platform:  p.ctx.Platform().Name,
+		Project:   p.Name,
+		BuildType: p.ctx.BuildType(),
+		Ports:     graph,
+	})
+
+	return lockfile.write()
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLock(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir, Dirs.WorkspaceDir = t.TempDir(), t.TempDir()
+	defer func() { Dirs = dirs }()
+
+	writeTestPort(t, "a@1", `"b@2"`)
+	writeTestPort(t, "b@2", ``)
+
+	ctx := NewBuildEnv()
+	ctx.project = Project{
+		Name:             "test_project",
+		ctx:              ctx,
+		resolvedVersions: map[string]string{"a": "1", "b": "2"},
+		lockedPorts: map[string]LockedPort{
+			"a": {Name: "a", Version: "1", Url: "https://example.com/a.git", Pattern: "*"},
+		},
+	}
+
+	// Newly added port is not an error, it would be added when writing lock.
+	if err := ctx.project.checkLock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.project.WriteLock(); err != nil {
+		t.Fatal(err)
+	}
+	lockfile, err := readLockfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := lockfile.findGraph("", "test_project", ctx.BuildType())
+	if graph == nil || len(graph.Ports) != 2 {
+		t.Fatalf("expected a and b in buildenv.lock, but got %+v", graph)
+	}
+
+	// Locked port that is changed is still an error.
+	ctx.project.lockedPorts["a"] = LockedPort{Name: "a", Version: "1", Url: "https://example.com/a-mirror.git", Pattern: "*"}
+	err = ctx.project.checkLock()
+	if err == nil || !strings.Contains(err.Error(), "url of a@1 is changed") {
+		t.Fatalf("expected url of a@1 changed, but got %v", err)
+	}
+}
+
+func TestWriteLockChecksum(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir, Dirs.WorkspaceDir, Dirs.DownloadedDir = t.TempDir(), t.TempDir(), t.TempDir()
+	defer func() { Dirs = dirs }()
+
+	// Archive is named after the first url, even if it's downloaded from the fallback url.
+	writeTestPortFile(t, "c@1", `{
+	"urls": ["https://example.com/c-1.tar.gz", "https://mirror.lan/c/v1.tar.gz"],
+	"build_configs": [{"pattern": "*", "build_tool": "cmake"}]
+}`)
+	archivePath := filepath.Join(Dirs.DownloadedDir, fileio.ArchiveName("https://example.com/c-1.tar.gz"))
+	if err := os.WriteFile(archivePath, []byte("archive"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := fileio.FileSha256(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := NewBuildEnv()
+	ctx.project = Project{
+		Name:             "test_project",
+		ctx:              ctx,
+		resolvedVersions: map[string]string{"c": "1"},
+		lockedPorts:      make(map[string]LockedPort),
+	}
+	if err := ctx.project.WriteLock(); err != nil {
+		t.Fatal(err)
+	}
+
+	lockfile, err := readLockfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := lockfile.findGraph("", "test_project", ctx.BuildType())
+	if graph == nil || len(graph.Ports) != 1 || graph.Ports[0].Sha256 != checksum {
+		t.Fatalf("expected sha256 %s of c@1, but got %+v", checksum, graph)
+	}
+}
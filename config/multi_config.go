@@ -0,0 +1,80 @@
+package config
+
+import (
+	"buildenv/generator"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// multiConfigFolder is the build type part of installed dir that merges all build types,
+// it's used by multi-config generators like `Ninja Multi-Config`.
+const multiConfigFolder = "multi"
+
+// ParseBuildTypes parses build types like `Debug,Release`, duplicated ones are ignored.
+func ParseBuildTypes(value string) ([]string, error) {
+	var buildTypes []string
+	for _, buildType := range strings.Split(value, ",") {
+		buildType = strings.TrimSpace(buildType)
+		if buildType == "" {
+			continue
+		}
+		if strings.ContainsAny(buildType, `^/\`) {
+			return nil, fmt.Errorf("invalid build type: %s", buildType)
+		}
+		if strings.EqualFold(buildType, multiConfigFolder) {
+			return nil, fmt.Errorf("build type %s is reserved for multi-config", buildType)
+		}
+
+		if !slices.ContainsFunc(buildTypes, func(item string) bool {
+			return strings.EqualFold(item, buildType)
+		}) {
+			buildTypes = append(buildTypes, buildType)
+		}
+	}
+
+	if len(buildTypes) == 0 {
+		return nil, fmt.Errorf("build type is empty")
+	}
+	return buildTypes, nil
+}
+
+// ResolveBuildTypes returns build types specified in command line,
+// or build_types defined in project when not specified, default is Release.
+func ResolveBuildTypes(value string) ([]string, error) {
+	if strings.TrimSpace(value) != "" {
+		return ParseBuildTypes(value)
+	}
+
+	buildenv := NewBuildEnv()
+	if err := buildenv.Init(filepath.Join(Dirs.WorkspaceDir, "buildenv.json")); err != nil {
+		return nil, err
+	}
+	if len(buildenv.Project().BuildTypes) == 0 {
+		return []string{"Release"}, nil
+	}
+	return ParseBuildTypes(strings.Join(buildenv.Project().BuildTypes, ","))
+}
+
+// MergeBuildTypes merges cmake configs of installed dirs of build types into `installed/<platform>^<project>^multi`,
+// nothing to do when only one build type.
+func (b buildenv) MergeBuildTypes(buildTypes []string) error {
+	if len(buildTypes) < 2 {
+		return nil
+	}
+
+	var configs []generator.InstalledConfig
+	for _, buildType := range buildTypes {
+		configs = append(configs, generator.InstalledConfig{
+			BuildType: buildType,
+			Dir:       filepath.Join(Dirs.InstalledDir, fmt.Sprintf("%s^%s^%s", b.PlatformName, b.ProjectName, buildType)),
+		})
+	}
+
+	prefix := filepath.Join(Dirs.InstalledDir, fmt.Sprintf("%s^%s^%s", b.PlatformName, b.ProjectName, multiConfigFolder))
+	if err := generator.MergeConfigs(prefix, configs); err != nil {
+		return fmt.Errorf("merge build types %s: %w", strings.Join(buildTypes, ","), err)
+	}
+	return nil
+}
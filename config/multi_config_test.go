@@ -0,0 +1,22 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBuildTypes(t *testing.T) {
+	buildTypes, err := ParseBuildTypes(" Debug, Release,debug,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(buildTypes, []string{"Debug", "Release"}) {
+		t.Fatalf("unexpected build types: %v", buildTypes)
+	}
+
+	for _, value := range []string{"", " , ", "Debug,multi", "Release^x"} {
+		if _, err := ParseBuildTypes(value); err == nil {
+			t.Fatalf("expected error of %q, but got nil", value)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"buildenv/pkg/cmd"
+	"buildenv/pkg/fileio"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CheckOffline reports all ports in graph that cannot be installed without network, so that
+// missing downloads and repos can be prepared at once, instead of failing at the first one.
+func CheckOffline(graph *Graph) error {
+	if !fileio.Offline() {
+		return nil
+	}
+
+	var missing []string
+	for _, node := range graph.Nodes {
+		if resource := node.Port.offlineMissing(); resource != "" {
+			missing = append(missing, fmt.Sprintf("%s: %s", node.Label(), resource))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("resources are missing in offline mode:\n    - %s", strings.Join(missing, "\n    - "))
+	}
+	return nil
+}
+
+// offlineMissing returns the missing resource to install port in offline mode, port can be installed
+// from installed files, package, cache dirs, cloned repo or downloaded archive.
+func (p Port) offlineMissing() string {
+	if p.Installed() || fileio.PathExists(p.packageDir) {
+		return ""
+	}
+
+	// Port without build_configs is a prebuilt archive.
+	if len(p.BuildConfigs) == 0 {
+		return p.offlineMissingArchive()
+	}
+
+	// Port that doesn't match current platform would be reported when installing.
+	matchedConfig, err := p.MatchedConfig()
+	if err != nil {
+		return ""
+	}
+
+	for _, cacheDir := range p.ctx.CacheDirs() {
+		if !cacheDir.Readable {
+			continue
+		}
+		archivePath := cacheDir.archivePath(p.ctx.Platform().Name, p.ctx.Project().Name, p.ctx.BuildType(), p.cacheArchiveName())
+		if fileio.PathExists(archivePath) {
+			return ""
+		}
+	}
+
+	sourceDir := matchedConfig.PortConfig.SourceDir
+	if fileio.PathExists(sourceDir) {
+		commit := matchedConfig.PortConfig.LockedCommit
+		if commit != "" && fileio.PathExists(filepath.Join(sourceDir, ".git")) && !cmd.HasCommit(sourceDir, commit) {
+			return fmt.Sprintf("commit %s is not fetched", commit)
+		}
+		return ""
+	}
+
+	if strings.HasSuffix(p.Url, ".git") {
+		return fmt.Sprintf("repo is not cloned from %s", p.Url)
+	}
+	return p.offlineMissingArchive()
+}
+
+// offlineMissingArchive checks archive named after url, fallback urls and their mirrors, the same as downloader.
+func (p Port) offlineMissingArchive() string {
+	archiveName := fileio.ArchiveName(p.Url)
+	if _, ok := fileio.FindArchive(Dirs.DownloadedDir, archiveName, append([]string{p.Url}, p.Urls...)...); ok {
+		return ""
+	}
+	return fmt.Sprintf("%s is not downloaded from %s", archiveName, p.Url)
+}
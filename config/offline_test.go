@@ -0,0 +1,63 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckOffline(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir, Dirs.WorkspaceDir, Dirs.InstalledDir, Dirs.DownloadedDir = t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	defer func() { Dirs = dirs }()
+
+	fileio.SetOffline(true)
+	defer fileio.SetOffline(false)
+
+	writeTestPort(t, "a@1", `"b@2"`)
+	writeTestPortFile(t, "b@2", `{
+	"url": "https://example.com/b-2.tar.gz",
+	"urls": ["https://mirror.lan/b/v2.tar.gz"],
+	"ref": "2",
+	"build_configs": [
+		{
+			"pattern": "*",
+			"build_tool": "cmake"
+		}
+	]
+}`)
+
+	graph, err := BuildGraph(NewBuildEnv(), []string{"a@1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All missing resources are reported at once.
+	err = CheckOffline(graph)
+	if err == nil {
+		t.Fatal("expected missing resources, but got nil")
+	}
+	for _, expected := range []string{
+		"a@1: repo is not cloned from https://example.com/a.git",
+		"b@2: b-2.tar.gz is not downloaded from https://example.com/b-2.tar.gz",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected %q in error, but got: %s", expected, err)
+		}
+	}
+
+	// Cloned source and downloaded archive are enough to install in offline mode.
+	sourceDir := graph.Roots[0].Port.BuildConfigs[0].PortConfig.SourceDir
+	if err := os.MkdirAll(sourceDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// Archive named after fallback url is also accepted.
+	if err := os.WriteFile(filepath.Join(Dirs.DownloadedDir, "v2.tar.gz"), []byte("archive"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckOffline(graph); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"buildenv/pkg/fileio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -17,6 +18,7 @@ type BuildEnvCallbacks interface {
 	OnCreateTool(toolName string) error
 	OnCreatePort(portNameVersion string) error
 	OnInitBuildEnv(confRepoUrl, confRepoRef string) (string, error)
+	OnShowGraph(buildType, format string, updateLock bool) (string, error)
 	About(version string) string
 }
 
@@ -87,13 +89,24 @@ func (p Platform) Write(platformPath string) error {
 }
 
 func (p Platform) Setup(args SetupArgs) error {
+	// Resources missing in offline mode are reported together.
+	var missing []string
+	checkOffline := func(err error) bool {
+		var offlineErr fileio.OfflineError
+		if errors.As(err, &offlineErr) {
+			missing = append(missing, offlineErr.Resource)
+			return true
+		}
+		return false
+	}
+
 	// RootFS maybe nil when platform is native.
 	if p.RootFS != nil {
 		if err := p.RootFS.Validate(); err != nil {
 			return err
 		}
 
-		if err := p.RootFS.CheckAndRepair(args); err != nil {
+		if err := p.RootFS.CheckAndRepair(args); err != nil && !checkOffline(err) {
 			return fmt.Errorf("buildenv.rootfs check and repair error: %w", err)
 		}
 	}
@@ -104,7 +117,7 @@ func (p Platform) Setup(args SetupArgs) error {
 			return fmt.Errorf("buildenv.toolchain error: %w", err)
 		}
 
-		if err := p.Toolchain.CheckAndRepair(args); err != nil {
+		if err := p.Toolchain.CheckAndRepair(args); err != nil && !checkOffline(err) {
 			return fmt.Errorf("buildenv.toolchain check and repair error: %w", err)
 		}
 	}
@@ -122,7 +135,7 @@ func (p Platform) Setup(args SetupArgs) error {
 			return fmt.Errorf("buildenv.tools[%s] validate error: %w", item, err)
 		}
 
-		if err := tool.CheckAndRepair(args); err != nil {
+		if err := tool.CheckAndRepair(args); err != nil && !checkOffline(err) {
 			return fmt.Errorf("buildenv.tools[%s] check and repair error: %w", item, err)
 		}
 
@@ -135,5 +148,8 @@ func (p Platform) Setup(args SetupArgs) error {
 		os.Setenv("PATH", absToolPath+string(os.PathListSeparator)+os.Getenv("PATH"))
 	}
 
+	if len(missing) > 0 {
+		return fmt.Errorf("resources are missing in offline mode:\n    - %s", strings.Join(missing, "\n    - "))
+	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"buildenv/pkg/color"
 	"buildenv/pkg/fileio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,41 +14,82 @@ import (
 )
 
 type Port struct {
+	Extends      string                    `json:"extends,omitempty"` // Base port to inherit from, like `1.2.11` or `name@version`.
 	Url          string                    `json:"url"`
+	Urls         []string                  `json:"urls,omitempty"` // Fallback urls tried in order when url is not accessible.
 	Ref          string                    `json:"ref"`
 	SourceFolder string                    `json:"source_folder,omitempty"`
+	Sha256       string                    `json:"sha256,omitempty"` // Optional sha256 of archive.
+	Sha512       string                    `json:"sha512,omitempty"` // Optional sha512 of archive.
 	BuildConfigs []buildsystem.BuildConfig `json:"build_configs"`
+	Features     map[string]Feature        `json:"features,omitempty"`
 
 	// Internal fields.
-	Name         string  `json:"-"`
-	Version      string  `json:"-"`
-	AsSubDep     bool    `json:"-"`
-	AsDev        bool    `json:"-"`
-	ctx          Context `json:"-"`
-	packageDir   string  `json:"-"`
-	installedDir string  `json:"-"`
-	stateFile    string  `json:"-"` // Used to record installed state
+	Name             string   `json:"-"`
+	Version          string   `json:"-"`
+	SelectedFeatures []string `json:"-"` // Sorted features enabled for current port.
+	AsSubDep         bool     `json:"-"`
+	AsDev            bool     `json:"-"`
+	ctx              Context  `json:"-"`
+	packageDir       string   `json:"-"`
+	installedDir     string   `json:"-"`
+	stateFile        string   `json:"-"` // Used to record installed state
 }
 
 func (p Port) NameVersion() string {
 	return p.Name + "@" + p.Version
 }
 
+// FullName returns name, version and selected features, like `curl@8.5.0[http2,ssl]`.
+func (p Port) FullName() string {
+	return p.NameVersion() + formatFeatures(p.SelectedFeatures)
+}
+
+// folderName is used to name folders and files of port, so that different combinations
+// of features won't collide, it's like `curl@8.5.0+http2+ssl`.
+func (p Port) folderName() string {
+	return portFolderName(p.Name, p.Version, p.SelectedFeatures)
+}
+
+func portFolderName(name, version string, features []string) string {
+	if len(features) == 0 {
+		return name + "@" + version
+	}
+	return name + "@" + version + featureSeparator + strings.Join(features, featureSeparator)
+}
+
+// PackageDir returns the dir where port is packaged.
+func (p Port) PackageDir() string {
+	return p.packageDir
+}
+
+// StateFile returns the file that records installed files of port.
+func (p Port) StateFile() string {
+	return p.stateFile
+}
+
 func (p *Port) Init(ctx Context, nameVersion string) error {
 	p.ctx = ctx
 
 	// Validate name and version.
-	if !strings.Contains(nameVersion, "@") {
-		return fmt.Errorf("port name and version are invalid %s", nameVersion)
+	name, constraint, features, err := splitPortRef(nameVersion)
+	if err != nil {
+		return err
 	}
-	parts := strings.Split(nameVersion, "@")
-	if len(parts) != 2 {
-		return fmt.Errorf("port name and version are invalid %s", nameVersion)
+
+	// Resolve version, it can be an exact version or a constraint like `>=1.2.11 <1.3`.
+	version, err := resolveVersion(ctx, name, constraint, p.AsDev)
+	if err != nil {
+		return err
 	}
 
 	// Parse name and version.
-	p.Name = parts[0]
-	p.Version = parts[1]
+	p.Name = name
+	p.Version = version
+	nameVersion = p.NameVersion()
+
+	// Features requested by other ports in project would also be enabled.
+	p.SelectedFeatures = mergeFeatures(features, ctx.Project().resolvedFeatures[resolveKey(name, p.AsDev)])
 
 	// Read name and version.
 	portFile := filepath.Join(Dirs.PortsDir, p.Name, p.Version+".json")
@@ -59,14 +101,23 @@ func (p *Port) Init(ctx Context, nameVersion string) error {
 		}
 	}
 
-	// Decode JSON.
-	bytes, err := os.ReadFile(portFile)
+	// Decode JSON, it would be merged with base port if it extends another one.
+	bytes, err := readPortFile(p.Name, p.Version)
 	if err != nil {
 		return err
 	}
 	if err := json.Unmarshal(bytes, p); err != nil {
 		return err
 	}
+	p.Url, p.Urls = primaryUrl(p.Url, p.Urls)
+
+	// Check if selected features are defined.
+	for _, feature := range p.SelectedFeatures {
+		if _, ok := p.Features[feature]; !ok {
+			return fmt.Errorf("feature %s is not defined in %s", feature, nameVersion)
+		}
+	}
+	folderName := p.folderName()
 
 	var (
 		installedFolder string
@@ -74,16 +125,17 @@ func (p *Port) Init(ctx Context, nameVersion string) error {
 		buildFolder     string
 	)
 	if p.AsDev {
-		packageFolder = nameVersion + "^dev"
+		packageFolder = folderName + "^dev"
 		installedFolder = "dev"
-		buildFolder = filepath.Join(nameVersion, "dev")
-		p.stateFile = filepath.Join(Dirs.InstalledDir, "buildenv", "info", nameVersion+"^dev.list")
+		buildFolder = filepath.Join(folderName, "dev")
+		p.stateFile = filepath.Join(Dirs.InstalledDir, "buildenv", "info", folderName+"^dev.list")
 	} else {
 		platformProject := fmt.Sprintf("%s^%s^%s", ctx.Platform().Name, ctx.Project().Name, ctx.BuildType())
-		packageFolder = fmt.Sprintf("%s^%s^%s^%s", nameVersion, ctx.Platform().Name, ctx.Project().Name, ctx.BuildType())
+		// Packages built with different project flags should not be reused.
+		packageFolder = fmt.Sprintf("%s^%s^%s^%s", p.cacheArchiveName(), ctx.Platform().Name, ctx.Project().Name, ctx.BuildType())
 		installedFolder = fmt.Sprintf("%s^%s^%s", ctx.Platform().Name, ctx.Project().Name, ctx.BuildType())
-		buildFolder = filepath.Join(nameVersion, fmt.Sprintf("%s^%s^%s", ctx.Platform().Name, ctx.Project().Name, ctx.BuildType()))
-		p.stateFile = filepath.Join(Dirs.InstalledDir, "buildenv", "info", nameVersion+"^"+platformProject+".list")
+		buildFolder = filepath.Join(folderName, fmt.Sprintf("%s^%s^%s", ctx.Platform().Name, ctx.Project().Name, ctx.BuildType()))
+		p.stateFile = filepath.Join(Dirs.InstalledDir, "buildenv", "info", folderName+"^"+platformProject+".list")
 	}
 	p.packageDir = filepath.Join(Dirs.WorkspaceDir, "packages", packageFolder)
 	p.installedDir = filepath.Join(Dirs.InstalledDir, installedFolder)
@@ -97,12 +149,31 @@ func (p *Port) Init(ctx Context, nameVersion string) error {
 		WorkspaceDir:    Dirs.WorkspaceDir,
 		PortsDir:        Dirs.PortsDir,
 		DownloadedDir:   Dirs.DownloadedDir,
-		SourceDir:       filepath.Join(Dirs.WorkspaceDir, "buildtrees", nameVersion, "src"),
+		SourceDir:       filepath.Join(Dirs.WorkspaceDir, "buildtrees", folderName, "src"),
 		BuildDir:        filepath.Join(Dirs.WorkspaceDir, "buildtrees", buildFolder),
 		PackageDir:      p.packageDir,
 		InstalledDir:    p.installedDir,
 		InstalledFolder: installedFolder,
-		TmpDir:          filepath.Join(Dirs.DownloadedDir, "tmp"),
+		TmpDir:          filepath.Join(Dirs.DownloadedDir, "tmp", folderName),
+		Sha256:          p.Sha256,
+		Sha512:          p.Sha512,
+		BuildType:       ctx.BuildType(),
+		Vars:            ctx.Project().vars,
+	}
+
+	// Flags defined in project are only for target, dev ports are built for host.
+	if !p.AsDev {
+		portConfig.CFlags = ctx.Project().CFlags.Resolve(ctx.BuildType())
+		portConfig.CXXFlags = ctx.Project().CXXFlags.Resolve(ctx.BuildType())
+		portConfig.LDFlags = ctx.Project().LDFlags.Resolve(ctx.BuildType())
+	}
+
+	// Reproduce the commit and archive recorded in buildenv.lock.
+	if locked, ok := p.lockedPort(); ok {
+		portConfig.LockedCommit = locked.Commit
+		if portConfig.Sha256 == "" {
+			portConfig.Sha256 = locked.Sha256
+		}
 	}
 
 	if p.ctx.RootFS() != nil {
@@ -117,6 +188,11 @@ func (p *Port) Init(ctx Context, nameVersion string) error {
 
 			// Merge project override ports.
 			p.mergeBuildConfig(&p.BuildConfigs[index], ctx.Project().OverridePorts)
+
+			// Apply selected features on top of build config.
+			for _, feature := range p.SelectedFeatures {
+				p.Features[feature].apply(&p.BuildConfigs[index])
+			}
 		}
 	}
 
@@ -155,16 +231,27 @@ func (p Port) Installed() bool {
 	}
 
 	// File can be read?
-	bytes, err := os.ReadFile(p.stateFile)
+	state, err := ReadStateFile(p.stateFile)
 	if err != nil {
 		return false
 	}
 
-	// No content?
-	if len(bytes) == 0 {
+	// No installed files?
+	if len(state.Files) == 0 {
+		return false
+	}
+
+	// Port should be installed again when project flags are changed.
+	if !p.AsDev && state.Flags != p.ctx.Project().flagsHash(p.ctx.BuildType()) {
 		return false
 	}
 
+	// Installed files may be removed by hand.
+	for _, file := range state.Files {
+		if _, err := os.Lstat(state.InstalledPath(file)); err != nil {
+			return false
+		}
+	}
 	return true
 }
 
@@ -175,7 +262,7 @@ func (p Port) Write(portPath string) error {
 	p.SourceFolder = "// [folder that contains CMakeLists.txt or configure or autoconf.sh]"
 	p.BuildConfigs = []buildsystem.BuildConfig{}
 	p.BuildConfigs = append(p.BuildConfigs, buildsystem.BuildConfig{
-		Pattern:   "// [*linux*|aarch64-linux*|*windows*|x86_64-windows]",
+		Pattern:   buildsystem.NewPattern("// [*linux*|aarch64-linux*|*windows*|x86_64-windows]"),
 		BuildTool: "// [b2|bazel|cmake|gyp|meson|ninja]",
 		SystemTools: []string{
 			"// [autoconf|libtool|...]",
@@ -204,6 +291,16 @@ func (p Port) Write(portPath string) error {
 		},
 		CMakeConfig: "// [linux-shared|linux-static|windows-shared|windows-static]",
 	})
+	p.Features = map[string]Feature{
+		"// [feature name]": {
+			Options: []string{
+				"// [--enable-xxx]",
+			},
+			Depedencies: []string{
+				"// [abc@v1.2.0]",
+			},
+		},
+	}
 	bytes, err := json.MarshalIndent(p, "", "    ")
 	if err != nil {
 		return err
@@ -229,9 +326,14 @@ func (p Port) Install(silentMode bool) error {
 	} else {
 		installedDir = filepath.Join(Dirs.WorkspaceDir, "installed", p.ctx.Platform().Name+"-"+p.ctx.BuildType())
 	}
+
+	// Last install may be interrupted, it would be rolled back or resumed.
+	if err := p.recoverInstall(); err != nil {
+		return fmt.Errorf("recover last install of %s: %w", p.FullName(), err)
+	}
 	if p.Installed() {
 		if !silentMode {
-			title := color.Sprintf(color.Green, "\n[✔] ---- Port: %s\n", p.NameVersion())
+			title := color.Sprintf(color.Green, "\n[✔] ---- Port: %s\n", p.FullName())
 			fmt.Printf("%sLocation: %s\n", title, installedDir)
 		}
 		return nil
@@ -246,33 +348,23 @@ func (p Port) Install(silentMode bool) error {
 		}
 
 		// This will copy all install files into installed dir.
-		if err := p.installFromPackage(nil); err != nil {
+		installedFrom = "archive"
+		if err := p.installFromPackage(nil, installedFrom); err != nil {
 			return err
 		}
-
-		installedFrom = "archive"
 	} else {
 		// Find matched config and init build system.
-		var matchedConfig *buildsystem.BuildConfig
-		for _, config := range p.BuildConfigs {
-			if p.MatchPattern(config.Pattern) {
-				if err := config.InitBuildSystem(); err != nil {
-					return err
-				}
-				matchedConfig = &config
-				break
-			}
-		}
-		if matchedConfig == nil {
-			return fmt.Errorf("no matching build_config found to build for %s", p.NameVersion())
+		matchedConfig, err := p.MatchedConfig()
+		if err != nil {
+			return err
 		}
 
 		// Install from package dir.
 		if fileio.PathExists(matchedConfig.PortConfig.PackageDir) {
-			if err := p.installFromPackage(matchedConfig.Depedencies); err != nil {
+			installedFrom = "package"
+			if err := p.installFromPackage(matchedConfig.Depedencies, installedFrom); err != nil {
 				return err
 			}
-			installedFrom = "package"
 		} else {
 			// Try to install from cache.
 			installed, fromDir, err := p.installFromCache(matchedConfig)
@@ -285,7 +377,7 @@ func (p Port) Install(silentMode bool) error {
 			} else {
 				// Remove build cache from buildtrees.
 				platformProject := fmt.Sprintf("%s^%s^%s", p.ctx.Platform().Name, p.ctx.Project().Name, p.ctx.BuildType())
-				logPathPrefix := filepath.Join(p.NameVersion(), platformProject)
+				logPathPrefix := filepath.Join(p.folderName(), platformProject)
 				p.tryRemoveBuildCache(logPathPrefix)
 
 				// Install from source when cache not found.
@@ -301,7 +393,7 @@ func (p Port) Install(silentMode bool) error {
 							continue
 						}
 
-						if err := cacheDir.Write(matchedConfig.PortConfig.PackageDir); err != nil {
+						if err := cacheDir.Write(matchedConfig.PortConfig.PackageDir, p.cacheArchiveName()); err != nil {
 							return err
 						}
 					}
@@ -310,41 +402,57 @@ func (p Port) Install(silentMode bool) error {
 				installedFrom = "source"
 			}
 
-			// This will copy all install files into installed dir.
-			if err := p.installFromPackage(matchedConfig.Depedencies); err != nil {
+			// This will copy all install files into installed dir, and write installed files into state file.
+			if err := p.installFromPackage(matchedConfig.Depedencies, installedFrom); err != nil {
 				return err
 			}
 		}
 	}
 
-	// Write installed files info into its installation info list.
-	if err := os.MkdirAll(filepath.Dir(p.stateFile), os.ModePerm); err != nil {
-		return err
-	}
-	packageFiles, err := p.PackageFiles(
-		p.packageDir,
-		p.ctx.Platform().Name,
-		p.ctx.Project().Name,
-		p.ctx.BuildType(),
-	)
-	if err != nil {
-		return err
-	}
-	if err := os.WriteFile(p.stateFile, []byte(strings.Join(packageFiles, "\n")), os.ModePerm); err != nil {
-		return err
-	}
-
 	// Print install info when not in silent mode.
 	if !silentMode {
 		title := color.Sprintf(color.Green, "\n[✔] ---- Port: %s, installed from %s\n",
-			p.NameVersion(), installedFrom)
+			p.FullName(), installedFrom)
 		fmt.Printf("%sLocation: %s\n", title, installedDir)
 	}
 
 	return nil
 }
 
-func (p Port) MatchPattern(pattern string) bool {
+// MatchPattern checks if build_config's pattern matches current platform,
+// pattern can be a platform name or attributes of platform.
+func (p Port) MatchPattern(pattern buildsystem.Pattern) bool {
+	return pattern.Match(p.patternValues(), p.matchPlatformName)
+}
+
+// patternValues returns attributes of current platform that can be matched by pattern,
+// for dev mode, they're attributes of host machine.
+func (p Port) patternValues() map[string]string {
+	values := map[string]string{
+		"platform":     p.ctx.Platform().Name,
+		"system_name":  p.ctx.SystemName(),
+		"processor":    p.ctx.SystemProcessor(),
+		"library_type": p.ctx.Project().LibraryType,
+	}
+
+	if toolchain := p.ctx.Toolchain(); toolchain != nil {
+		values["host"] = toolchain.Host
+	}
+
+	if p.AsDev {
+		values["system_name"] = runtime.GOOS
+		values["processor"] = buildsystem.HostProcessor()
+		values["host"] = ""
+	}
+
+	native := p.AsDev || p.ctx.Toolchain() == nil ||
+		(strings.EqualFold(values["system_name"], runtime.GOOS) && values["processor"] == buildsystem.HostProcessor())
+	values["native"] = fmt.Sprintf("%t", native)
+
+	return values
+}
+
+func (p Port) matchPlatformName(pattern string) bool {
 	pattern = strings.TrimSpace(pattern)
 
 	if pattern == "" || pattern == "*" {
@@ -385,6 +493,20 @@ func (p Port) MatchPattern(pattern string) bool {
 	return platformName == pattern
 }
 
+// MatchedConfig returns the first build config that matches current platform, with its build system inited.
+func (p Port) MatchedConfig() (*buildsystem.BuildConfig, error) {
+	for _, config := range p.BuildConfigs {
+		if p.MatchPattern(config.Pattern) {
+			if err := config.InitBuildSystem(); err != nil {
+				return nil, err
+			}
+			return &config, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no matching build_config found to build for %s", p.NameVersion())
+}
+
 func (p Port) PackageFiles(packageDir, platformName, projectName, buildType string) ([]string, error) {
 	if !fileio.PathExists(packageDir) {
 		return nil, nil
@@ -448,7 +570,7 @@ func (p Port) installFromCache(matchedConfig *buildsystem.BuildConfig) (installe
 			p.ctx.Platform().Name,
 			p.ctx.Project().Name,
 			p.ctx.BuildType(),
-			p.NameVersion()+".tar.gz",
+			p.cacheArchiveName(),
 			matchedConfig.PortConfig.PackageDir,
 		)
 		if err != nil {
@@ -462,11 +584,20 @@ func (p Port) installFromCache(matchedConfig *buildsystem.BuildConfig) (installe
 	return false, "", nil
 }
 
+// cacheArchiveName returns name of package archive in cache dirs without suffix, which depends on
+// compression of cache dir, packages built with different project flags would not be shared.
+func (p Port) cacheArchiveName() string {
+	if hash := p.ctx.Project().flagsHash(p.ctx.BuildType()); hash != "" {
+		return fmt.Sprintf("%s-%s", p.folderName(), hash)
+	}
+	return p.folderName()
+}
+
 func (p Port) installFromSource(silentMode bool, buildConfig *buildsystem.BuildConfig) error {
 	// 1. check and repair dev_dependencies.
 	for _, nameVersion := range buildConfig.DevDepedencies {
 		// Skip self.
-		if p.AsDev && p.NameVersion() == nameVersion {
+		if name, _, err := splitNameVersion(nameVersion); err == nil && p.AsDev && p.Name == name {
 			continue
 		}
 
@@ -484,10 +615,6 @@ func (p Port) installFromSource(silentMode bool, buildConfig *buildsystem.BuildC
 
 	// 2. check and repair dependencies.
 	for _, nameVersion := range buildConfig.Depedencies {
-		if strings.HasPrefix(nameVersion, p.Name) {
-			return fmt.Errorf("%s's dependencies contains circular dependency: %s", p.NameVersion(), nameVersion)
-		}
-
 		// Check and repair dependency.
 		var port Port
 		port.AsDev = p.AsDev
@@ -501,25 +628,31 @@ func (p Port) installFromSource(silentMode bool, buildConfig *buildsystem.BuildC
 	}
 
 	// Check and repair current port.
-	if err := buildConfig.Install(p.Url, p.Ref, p.ctx.BuildType()); err != nil {
+	if err := buildConfig.Install(append([]string{p.Url}, p.Urls...), p.Ref, p.ctx.BuildType()); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (p Port) installFromPackage(depedencies []string) error {
+// installFromPackage installs files of dependencies and current port from their packages transactionally,
+// files are staged first, then moved into installed dir, the state file is written at last.
+func (p Port) installFromPackage(depedencies []string, from string) error {
 	platformProject := fmt.Sprintf("%s^%s^%s", p.ctx.Platform().Name, p.ctx.Project().Name, p.ctx.BuildType())
+	journal := p.newInstallJournal(from)
 
 	// First, we must check and repair dependency ports.
-	for _, nameRef := range depedencies {
-		if strings.HasPrefix(nameRef, p.Name) {
-			return fmt.Errorf("port.dependencies contains circular dependency: %s", nameRef)
+	for _, nameVersion := range depedencies {
+		// Dependency may be a version constraint, init it to locate its package dir.
+		var port Port
+		port.AsDev = p.AsDev
+		port.AsSubDep = true
+		if err := port.Init(p.ctx, nameVersion); err != nil {
+			return err
 		}
 
-		packageDir := filepath.Join(Dirs.WorkspaceDir, "packages", nameRef+"^"+platformProject)
 		packageFiles, err := p.PackageFiles(
-			packageDir,
+			port.packageDir,
 			p.ctx.Platform().Name,
 			p.ctx.Project().Name,
 			p.ctx.BuildType(),
@@ -530,15 +663,7 @@ func (p Port) installFromPackage(depedencies []string) error {
 
 		for _, file := range packageFiles {
 			file = strings.TrimPrefix(file, platformProject+"/")
-			src := filepath.Join(packageDir, file)
-			dest := filepath.Join(p.installedDir, file)
-
-			if err := os.MkdirAll(filepath.Dir(dest), os.ModeDir|os.ModePerm); err != nil {
-				return err
-			}
-			if err := fileio.CopyFile(src, dest); err != nil {
-				return err
-			}
+			journal.add(filepath.Join(port.packageDir, file), file)
 		}
 	}
 
@@ -552,37 +677,50 @@ func (p Port) installFromPackage(depedencies []string) error {
 	if err != nil {
 		return err
 	}
-
-	// No files found, skip it, maybe need to install from source.
-	if len(packageFiles) == 0 {
-		return nil
-	}
-
-	// Copy files from package to installed dir.
 	for _, file := range packageFiles {
 		if p.AsDev {
 			file = strings.TrimPrefix(file, "dev/")
 		} else {
 			file = strings.TrimPrefix(file, platformProject+"/")
 		}
+		journal.add(filepath.Join(p.packageDir, file), file)
+	}
+	journal.StateFiles = packageFiles
+	if !p.AsDev {
+		journal.Flags = p.ctx.Project().flagsHash(p.ctx.BuildType())
+	}
 
-		src := filepath.Join(p.packageDir, file)
-		dest := filepath.Join(p.installedDir, file)
+	if err := journal.stage(); err != nil {
+		return err
+	}
 
-		if err := os.MkdirAll(filepath.Dir(dest), os.ModeDir|os.ModePerm); err != nil {
-			return err
-		}
-		if err := fileio.CopyFile(src, dest); err != nil {
-			return err
-		}
+	// Ports may be installed by concurrent processes, conflicts are checked and files are moved exclusively.
+	unlock, err := lockInstall()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	return nil
+	// Files installed by other ports should not be overwritten silently.
+	if err := p.checkFileConflicts(packageFiles); err != nil {
+		return errors.Join(err, journal.rollback())
+	}
+	return journal.commit()
 }
 
 func (p Port) downloadAndDeploy(url string) error {
-	tmpDir := filepath.Join(Dirs.DownloadedDir, "tmp")
-	repair := fileio.NewDownloadRepair(url, filepath.Base(url), ".", tmpDir, Dirs.DownloadedDir)
+	// Every port is extracted into its own tmp dir, since ports may be installed at the same time.
+	tmpDir := filepath.Join(Dirs.DownloadedDir, "tmp", p.folderName())
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	repair := fileio.NewDownloadRepair(url, fileio.ArchiveName(url), ".", tmpDir, Dirs.DownloadedDir)
+	repair.SetFallbackUrls(p.Urls...).SetSha256(p.Sha256).SetSha512(p.Sha512)
+	if locked, ok := p.lockedPort(); ok && p.Sha256 == "" {
+		repair.SetSha256(locked.Sha256)
+	}
 	if err := repair.CheckAndRepair(); err != nil {
 		return err
 	}
@@ -606,6 +744,16 @@ func (p Port) downloadAndDeploy(url string) error {
 	return nil
 }
 
+// lockedPort returns the port recorded in buildenv.lock with the same version.
+func (p Port) lockedPort() (LockedPort, bool) {
+	locked, ok := p.ctx.Project().lockedPorts[resolveKey(p.Name, p.AsDev)]
+	if !ok || locked.Version != p.Version {
+		return LockedPort{}, false
+	}
+
+	return locked, true
+}
+
 func (p Port) buildCrossTools() buildsystem.CrossTools {
 	crossTools := buildsystem.CrossTools{
 		SystemName:      p.ctx.SystemName(),
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// appendSuffix marks a list field to be appended to the one of base port, like `"options+": ["-DBUILD_TESTING=OFF"]`,
+// list field without this suffix would override the one of base port.
+const appendSuffix = "+"
+
+// readPortFile reads `conf/ports/<name>/<version>.json`, if it has `extends` field,
+// it would be deep merged on top of the base port with below rules:
+//   - object fields, like `features`, are merged key by key.
+//   - entries of `build_configs` are matched by `pattern`, matched ones are merged, others are appended.
+//   - list fields override the base ones, or append to them when suffixed with `+`.
+//   - other fields override the base ones, `sha256` and `sha512` are dropped when `url` is overridden.
+//   - patches of base port with another name are still found in the base port's folder.
+func readPortFile(name, version string) ([]byte, error) {
+	merged, err := readPortMap(name, version, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
+func readPortMap(name, version string, chain []string) (map[string]any, error) {
+	nameVersion := name + "@" + version
+	chain = append(chain, nameVersion)
+
+	// Check if port extends itself directly or indirectly.
+	for _, item := range chain[:len(chain)-1] {
+		if item == nameVersion {
+			return nil, fmt.Errorf("circular extends of port: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	portFile := filepath.Join(Dirs.PortsDir, name, version+".json")
+	if !fileio.PathExists(portFile) {
+		if len(chain) > 1 {
+			return nil, fmt.Errorf("base port %s extended by %s does not exists", nameVersion, chain[len(chain)-2])
+		}
+		return nil, fmt.Errorf("port %s does not exists", nameVersion)
+	}
+
+	bytes, err := os.ReadFile(portFile)
+	if err != nil {
+		return nil, err
+	}
+	var port map[string]any
+	if err := json.Unmarshal(bytes, &port); err != nil {
+		return nil, fmt.Errorf("read %s error: %w", portFile, err)
+	}
+
+	// No base port, so there is nothing to append to.
+	extends, ok := port["extends"]
+	if !ok {
+		if keys := appendKeys(port); len(keys) > 0 {
+			return nil, fmt.Errorf("%s: %s can only be used in port with extends", nameVersion, strings.Join(keys, ", "))
+		}
+		return port, nil
+	}
+
+	// Base can be a version of the same port, or `name@version` of another port.
+	base, ok := extends.(string)
+	if !ok || strings.TrimSpace(base) == "" {
+		return nil, fmt.Errorf("extends of %s should be a version or name@version", nameVersion)
+	}
+	baseName, baseVersion := name, strings.TrimSpace(base)
+	if strings.Contains(baseVersion, "@") {
+		baseName, baseVersion, err = splitNameVersion(baseVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	basePort, err := readPortMap(baseName, baseVersion, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Patches are looked up in the folder of port, so base ones should refer to folder of base port.
+	if baseName != name {
+		rebasePatches(basePort, baseName)
+	}
+
+	// Checksums belong to the archive of base port.
+	if _, ok := port["url"]; ok {
+		delete(basePort, "sha256")
+		delete(basePort, "sha512")
+	}
+
+	return mergePortMap(basePort, port), nil
+}
+
+// appendKeys returns sorted keys suffixed with `+` in port, including nested ones.
+func appendKeys(value any) []string {
+	var keys []string
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if strings.HasSuffix(key, appendSuffix) {
+				keys = append(keys, strconv.Quote(key))
+			}
+			keys = append(keys, appendKeys(item)...)
+		}
+	case []any:
+		for _, item := range value {
+			keys = append(keys, appendKeys(item)...)
+		}
+	}
+
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
+
+// rebasePatches makes patches of build configs and features relative to the folder of base port.
+func rebasePatches(port map[string]any, baseName string) {
+	rebase := func(item any) {
+		config, ok := item.(map[string]any)
+		if !ok {
+			return
+		}
+		patches, _ := config["patches"].([]any)
+		for index, patch := range patches {
+			if patch, ok := patch.(string); ok && strings.TrimSpace(patch) != "" {
+				patches[index] = filepath.ToSlash(filepath.Join("..", baseName, strings.TrimSpace(patch)))
+			}
+		}
+	}
+
+	buildConfigs, _ := port["build_configs"].([]any)
+	for _, item := range buildConfigs {
+		rebase(item)
+	}
+	features, _ := port["features"].(map[string]any)
+	for _, item := range features {
+		rebase(item)
+	}
+}
+
+// mergePortMap merges override on top of base, base is modified and returned.
+func mergePortMap(base, override map[string]any) map[string]any {
+	for key, value := range override {
+		// Append list to the base one.
+		if strings.HasSuffix(key, appendSuffix) {
+			key = strings.TrimSuffix(key, appendSuffix)
+			baseList, _ := base[key].([]any)
+			if list, ok := value.([]any); ok {
+				base[key] = append(baseList, list...)
+				continue
+			}
+		}
+
+		switch value := value.(type) {
+		case map[string]any:
+			if baseMap, ok := base[key].(map[string]any); ok {
+				base[key] = mergePortMap(baseMap, value)
+			} else {
+				base[key] = mergePortMap(make(map[string]any), value)
+			}
+
+		case []any:
+			if key == "build_configs" {
+				baseList, _ := base[key].([]any)
+				base[key] = mergeBuildConfigs(baseList, value)
+			} else {
+				base[key] = value
+			}
+
+		default:
+			base[key] = value
+		}
+	}
+
+	return base
+}
+
+// mergeBuildConfigs merges build configs with the same pattern, and appends the rest.
+func mergeBuildConfigs(base, override []any) []any {
+	for _, item := range override {
+		config, ok := item.(map[string]any)
+		if !ok {
+			base = append(base, item)
+			continue
+		}
+
+		merged := false
+		for index, baseItem := range base {
+			baseConfig, ok := baseItem.(map[string]any)
+			// Pattern may be an object, so compare it deeply.
+			if ok && reflect.DeepEqual(baseConfig["pattern"], config["pattern"]) {
+				base[index] = mergePortMap(baseConfig, config)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			base = append(base, mergePortMap(make(map[string]any), config))
+		}
+	}
+
+	return base
+}
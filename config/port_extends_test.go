@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeTestPortFile(t *testing.T, nameVersion, content string) {
+	name, version, err := splitNameVersion(nameVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portPath := filepath.Join(Dirs.PortsDir, name, version+".json")
+	if err := os.MkdirAll(filepath.Dir(portPath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(portPath, []byte(content), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPortExtends(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPortFile(t, "zlib@1.2.11", `{
+	"url": "https://example.com/zlib-1.2.11.tar.gz",
+	"sha256": "c3e5e9fdd5004dcb542feda5ee4f0ff0744628baf8ed2dd5d66f8ca1197cb1a1",
+	"build_configs": [
+		{
+			"pattern": "*linux*",
+			"build_tool": "cmake",
+			"library_type": "shared",
+			"options": ["-DZLIB_BUILD_EXAMPLES=OFF"]
+		},
+		{
+			"pattern": "*windows*",
+			"build_tool": "cmake",
+			"options": ["-DZLIB_BUILD_EXAMPLES=OFF"]
+		}
+	],
+	"features": {
+		"minizip": {"options": ["-DZLIB_MINIZIP=ON"]}
+	}
+}`)
+	writeTestPortFile(t, "zlib@1.3.1", `{
+	"extends": "1.2.11",
+	"url": "https://example.com/zlib-1.3.1.tar.gz",
+	"build_configs": [
+		{
+			"pattern": "*linux*",
+			"options+": ["-DZLIB_BUILD_TESTS=OFF"]
+		},
+		{
+			"pattern": "*darwin*",
+			"build_tool": "cmake"
+		}
+	]
+}`)
+
+	var port Port
+	if err := port.Init(NewBuildEnv(), "zlib@1.3.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if port.Url != "https://example.com/zlib-1.3.1.tar.gz" || port.Sha256 != "" {
+		t.Fatalf("unexpected url or sha256: %s %s", port.Url, port.Sha256)
+	}
+	if len(port.BuildConfigs) != 3 {
+		t.Fatalf("expected 3 build_configs but got %d", len(port.BuildConfigs))
+	}
+
+	linux := port.BuildConfigs[0]
+	if linux.Pattern.Name != "*linux*" || linux.BuildTool != "cmake" || linux.LibraryType != "shared" {
+		t.Fatalf("unexpected build_config: %+v", linux)
+	}
+	if !slices.Equal(linux.Options, []string{"-DZLIB_BUILD_EXAMPLES=OFF", "-DZLIB_BUILD_TESTS=OFF"}) {
+		t.Fatalf("unexpected options: %v", linux.Options)
+	}
+	if port.BuildConfigs[1].Pattern.Name != "*windows*" || port.BuildConfigs[2].Pattern.Name != "*darwin*" {
+		t.Fatalf("unexpected build_configs: %+v", port.BuildConfigs)
+	}
+	if _, ok := port.Features["minizip"]; !ok {
+		t.Fatal("expected feature minizip inherited")
+	}
+}
+
+func TestPortExtendsCircular(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPortFile(t, "a@1", `{"extends": "b@2", "url": "https://example.com/a.git"}`)
+	writeTestPortFile(t, "b@2", `{"extends": "a@1", "url": "https://example.com/b.git"}`)
+
+	var port Port
+	err := port.Init(NewBuildEnv(), "a@1")
+	if err == nil || !strings.Contains(err.Error(), "circular extends of port: a@1 -> b@2 -> a@1") {
+		t.Fatalf("expected circular extends error but got %v", err)
+	}
+}
+
+func TestPortExtendsPatches(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPortFile(t, "zlib@1.3.1", `{
+	"url": "https://example.com/zlib.git",
+	"build_configs": [
+		{
+			"pattern": "*",
+			"build_tool": "cmake",
+			"patches": ["fix-install.patch"]
+		}
+	],
+	"features": {
+		"minizip": {"patches": ["fix-minizip.patch"]}
+	}
+}`)
+	writeTestPortFile(t, "zlib-ng@2.2.2", `{
+	"extends": "zlib@1.3.1",
+	"url": "https://example.com/zlib-ng.git",
+	"build_configs": [
+		{
+			"pattern": "*",
+			"patches+": ["fix-ng.patch"]
+		}
+	]
+}`)
+
+	var port Port
+	if err := port.Init(NewBuildEnv(), "zlib-ng@2.2.2[minizip]"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Patches are applied from `conf/ports/<name>`, base ones should be found in folder of base port.
+	expected := []string{"../zlib/fix-install.patch", "fix-ng.patch", "../zlib/fix-minizip.patch"}
+	if patches := port.BuildConfigs[0].Patches; !slices.Equal(patches, expected) {
+		t.Fatalf("expected patches %v but got %v", expected, patches)
+	}
+	if patches := port.Features["minizip"].Patches; !slices.Equal(patches, []string{"../zlib/fix-minizip.patch"}) {
+		t.Fatalf("unexpected patches of feature: %v", patches)
+	}
+	if patchPath := filepath.Join(Dirs.PortsDir, "zlib-ng", expected[0]); patchPath != filepath.Join(Dirs.PortsDir, "zlib", "fix-install.patch") {
+		t.Fatalf("unexpected patch path: %s", patchPath)
+	}
+}
+
+func TestPortAppendWithoutExtends(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPortFile(t, "zlib@1.3.1", `{
+	"url": "https://example.com/zlib.git",
+	"build_configs": [
+		{
+			"pattern": "*",
+			"build_tool": "cmake",
+			"options+": ["-DZLIB_BUILD_TESTS=OFF"]
+		}
+	]
+}`)
+
+	var port Port
+	err := port.Init(NewBuildEnv(), "zlib@1.3.1")
+	if err == nil || !strings.Contains(err.Error(), `zlib@1.3.1: "options+" can only be used in port with extends`) {
+		t.Fatalf("expected append without extends error but got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PortSummary is a port in conf repo with all its available versions, highest first.
+type PortSummary struct {
+	Name     string
+	Versions []string
+}
+
+// PortInfo is a raw port config read from conf repo, it's not initialized for any platform.
+type PortInfo struct {
+	Port
+	HasCMakeConfig bool // `<version>@cmake_config.json` exists.
+}
+
+// SearchPorts returns ports whose name contain the pattern, or match it when the pattern is a glob like `lib*`.
+func SearchPorts(pattern string) ([]PortSummary, error) {
+	entities, err := os.ReadDir(Dirs.PortsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	isGlob := strings.ContainsAny(pattern, "*?[")
+
+	var summaries []PortSummary
+	for _, entity := range entities {
+		if !entity.IsDir() {
+			continue
+		}
+
+		name := entity.Name()
+		if isGlob {
+			matched, err := filepath.Match(pattern, strings.ToLower(name))
+			if err != nil {
+				return nil, fmt.Errorf("invalid search pattern: %w", err)
+			}
+			if !matched {
+				continue
+			}
+		} else if !strings.Contains(strings.ToLower(name), pattern) {
+			continue
+		}
+
+		versions, err := portVersions(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			continue
+		}
+		sortVersions(versions)
+		summaries = append(summaries, PortSummary{Name: name, Versions: versions})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
+
+// ReadPortInfos reads all versions of port that match `name`, `name@version` or `name@constraint`, highest first.
+func ReadPortInfos(nameVersion string) ([]PortInfo, error) {
+	name, constraint, err := splitNameVersion(nameVersion)
+	if err != nil {
+		if strings.Contains(nameVersion, "@") {
+			return nil, err
+		}
+		name, constraint = nameVersion, ""
+	}
+
+	var versions []string
+	if constraint == "" {
+		versions, err = portVersions(name)
+		sortVersions(versions)
+	} else {
+		versions, err = matchVersions(name, constraint)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("port %s is not found", nameVersion)
+	}
+
+	var infos []PortInfo
+	for _, version := range versions {
+		bytes, err := readPortFile(name, version)
+		if err != nil {
+			return nil, err
+		}
+
+		var info PortInfo
+		if err := json.Unmarshal(bytes, &info.Port); err != nil {
+			return nil, fmt.Errorf("read %s@%s error: %w", name, version, err)
+		}
+		info.Name = name
+		info.Version = version
+		info.HasCMakeConfig = fileio.PathExists(filepath.Join(Dirs.PortsDir, name, version+"@cmake_config.json"))
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
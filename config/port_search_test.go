@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSearchPorts(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPort(t, "zlib@1.2.13", ``)
+	writeTestPort(t, "zlib@1.3.1", ``)
+	writeTestPort(t, "libpng@1.6.43", `"zlib@>=1.2"`)
+	writeTestPort(t, "libjpeg@9f", ``)
+
+	summaries, err := SearchPorts("lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, summary := range summaries {
+		names = append(names, summary.Name)
+	}
+	if !slices.Equal(names, []string{"libjpeg", "libpng", "zlib"}) {
+		t.Fatalf("unexpected search result: %v", names)
+	}
+
+	summaries, err = SearchPorts("z*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 1 || !slices.Equal(summaries[0].Versions, []string{"1.3.1", "1.2.13"}) {
+		t.Fatalf("unexpected search result: %v", summaries)
+	}
+}
+
+func TestReadPortInfos(t *testing.T) {
+	portsDir := Dirs.PortsDir
+	Dirs.PortsDir = t.TempDir()
+	defer func() { Dirs.PortsDir = portsDir }()
+
+	writeTestPort(t, "zlib@1.2.13", ``)
+	writeTestPort(t, "zlib@1.3.1", ``)
+	cmakeConfigPath := filepath.Join(Dirs.PortsDir, "zlib", "1.3.1@cmake_config.json")
+	if err := os.WriteFile(cmakeConfigPath, []byte("{}"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := ReadPortInfos("zlib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Version != "1.3.1" || !infos[0].HasCMakeConfig || infos[1].HasCMakeConfig {
+		t.Fatalf("unexpected port infos: %+v", infos)
+	}
+	if infos[0].BuildConfigs[0].BuildTool != "cmake" {
+		t.Fatalf("unexpected build tool: %s", infos[0].BuildConfigs[0].BuildTool)
+	}
+
+	infos, err = ReadPortInfos("zlib@<1.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Version != "1.2.13" {
+		t.Fatalf("unexpected port infos: %+v", infos)
+	}
+
+	if _, err := ReadPortInfos("zlib@2.0"); err == nil {
+		t.Fatal("expected error for missing version")
+	}
+}
@@ -7,26 +7,30 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
 )
 
 type Project struct {
-	Ports         []string                           `json:"ports"`
-	OverridePorts map[string]buildsystem.BuildConfig `json:"override_ports"`
-	CMakeVars     []string                           `json:"cmake_vars"`
-	EnvVars       []string                           `json:"env_vars"`
-	MicroVars     []string                           `json:"micro_vars"`
+	Ports          []string                           `json:"ports"`
+	OverridePorts  map[string]buildsystem.BuildConfig `json:"override_ports"`
+	CMakeVars      []string                           `json:"cmake_vars"`
+	EnvVars        []string                           `json:"env_vars"`
+	MicroVars      []string                           `json:"micro_vars"`
+	Vars           []string                           `json:"vars,omitempty"`            // Vars like `KEY=VALUE`, they can be used as placeholders like ${KEY} in ports.
+	CFlags         BuildFlags                         `json:"c_flags,omitempty"`         // C flags for ports and consumers, defined per build type.
+	CXXFlags       BuildFlags                         `json:"cxx_flags,omitempty"`       // C++ flags for ports and consumers, defined per build type.
+	LDFlags        BuildFlags                         `json:"ld_flags,omitempty"`        // Linker flags for ports and consumers, defined per build type.
+	BuildTypes     []string                           `json:"build_types,omitempty"`     // Build types to setup when not specified in command line, like ["Debug", "Release"].
+	LibraryType    string                             `json:"library_type,omitempty"`    // Preferred library type, it can be matched by pattern of build_config.
+	AllowOverwrite []string                           `json:"allow_overwrite,omitempty"` // Files that can be installed by more than one port, like "include/config.h" or "lib/pkgconfig/*.pc".
 
 	// Internal fields.
-	Name          string                    `json:"-"`
-	ctx           Context                   `json:"-"`
-	trackingPorts map[string][]trackingInfo `json:"-"`
-}
-
-type trackingInfo struct {
-	version string
-	parent  string
+	Name             string                `json:"-"`
+	ctx              Context               `json:"-"`
+	resolvedVersions map[string]string     `json:"-"` // Resolved version of every port in dependency graph.
+	resolvedFeatures map[string][]string   `json:"-"` // Features of every port requested in dependency graph.
+	lockedPorts      map[string]LockedPort `json:"-"` // Ports locked in buildenv.lock.
+	vars             map[string]string     `json:"-"` // Parsed vars, they're used to expand placeholders.
 }
 
 func (p *Project) Init(ctx Context, projectName string) error {
@@ -55,6 +59,32 @@ func (p *Project) Init(ctx Context, projectName string) error {
 
 	// Set values of internal fields.
 	p.Name = projectName
+	p.resolvedVersions = make(map[string]string)
+	p.resolvedFeatures = make(map[string][]string)
+	p.lockedPorts = make(map[string]LockedPort)
+	p.vars = make(map[string]string)
+	for _, item := range p.Vars {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || !envVarKeyRegex.MatchString(strings.TrimSpace(key)) {
+			return fmt.Errorf("invalid var: %s, it should be like KEY=VALUE", item)
+		}
+		p.vars[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	// Check build types of project flags.
+	if err := p.CFlags.Validate(); err != nil {
+		return fmt.Errorf("invalid c_flags: %w", err)
+	}
+	if err := p.CXXFlags.Validate(); err != nil {
+		return fmt.Errorf("invalid cxx_flags: %w", err)
+	}
+	if err := p.LDFlags.Validate(); err != nil {
+		return fmt.Errorf("invalid ld_flags: %w", err)
+	}
+
+	if err := validateAllowOverwrite(p.AllowOverwrite); err != nil {
+		return fmt.Errorf("invalid allow_overwrite: %w", err)
+	}
 	return nil
 }
 
@@ -82,119 +112,72 @@ func (p Project) Write(platformPath string) error {
 	return os.WriteFile(platformPath, bytes, os.ModePerm)
 }
 
-func (p Project) Setup(args SetupArgs) error {
-	// Check if ports version conflicts in the project.
-	if err := p.checkPortsConflicts(); err != nil {
-		return err
+// Resolve resolves ports of project and builds their dependency graph,
+// it only reads conf repo and buildenv.lock, nothing would be downloaded.
+func (p Project) Resolve(updateLock bool) (*Graph, error) {
+	// Ports would be pinned to versions in buildenv.lock unless update lock.
+	if err := p.loadLock(updateLock); err != nil {
+		return nil, err
 	}
 
-	// Validate dependencies.
-	validatePort := func(nameVersion string) error {
-		var port Port
-		if err := port.Init(p.ctx, nameVersion); err != nil {
-			return fmt.Errorf("%s: %w", nameVersion, err)
-		}
-
-		if err := port.Validate(); err != nil {
-			return fmt.Errorf("%s: %w", nameVersion, err)
-		}
-
-		if args.InstallPorts() {
-			if err := port.Install(args.Silent()); err != nil {
-				return fmt.Errorf("%s: %w", nameVersion, err)
-			}
-		}
+	// Resolve one version for every port, conflicts would be reported.
+	if err := p.resolvePorts(); err != nil {
+		return nil, err
+	}
 
-		return nil
+	// Check circular dependencies before any download or build starts.
+	graph, err := BuildGraph(p.ctx, p.Ports, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := graph.DetectCycle(); err != nil {
+		return nil, err
 	}
 
-	for _, item := range p.Ports {
-		if err := validatePort(item); err != nil {
-			return err
-		}
+	// Make sure ports locked before are not changed.
+	if err := p.checkLock(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return graph, nil
 }
 
-func (p *Project) checkPortsConflicts() error {
-	p.trackingPorts = make(map[string][]trackingInfo)
-
-	for _, nameVersion := range p.Ports {
-		var port Port
-		if err := port.Init(p.ctx, nameVersion); err != nil {
-			return err
-		}
-
-		p.trackingPorts[port.Name] = []trackingInfo{
-			{
-				version: port.Version,
-				parent:  p.Name,
-			},
-		}
-
-		if err := p.trackingPortDepedencies(port); err != nil {
-			return err
-		}
+func (p Project) Setup(args SetupArgs) error {
+	graph, err := p.Resolve(args.UpdateLock())
+	if err != nil {
+		return err
 	}
 
-	// Check if there are any conflicts.
-	var summaries []string
-	for portName, trackingInfos := range p.trackingPorts {
-		if len(trackingInfos) > 1 {
-			var conflicts []string
-			for _, trackingInfo := range trackingInfos {
-				conflicts = append(conflicts, fmt.Sprintf("%s@%s is defined in %s", portName, trackingInfo.version, trackingInfo.parent))
-			}
-
-			summaries = append(summaries, fmt.Sprintf("    - %s", strings.Join(conflicts, ", ")))
+	// Validate ports.
+	for _, node := range graph.Roots {
+		if err := node.Port.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", node.Port.FullName(), err)
 		}
 	}
-	if len(summaries) > 0 {
-		return fmt.Errorf("detected conflicting versions of ports:\n%s", strings.Join(summaries, "\n"))
-	}
 
-	return nil
-}
+	// Independent ports would be built concurrently.
+	if args.InstallPorts() {
+		if err := CheckOffline(graph); err != nil {
+			return err
+		}
 
-func (p *Project) trackingPortDepedencies(port Port) error {
-	// Find matched config and init build system.
-	var matchedConfig *buildsystem.BuildConfig
-	for _, config := range port.BuildConfigs {
-		if port.MatchPattern(config.Pattern) {
-			if err := config.InitBuildSystem(); err != nil {
+		// Record resolved graph before building, so that ports built by child processes are pinned.
+		if !args.Locked() {
+			if err := p.WriteLock(); err != nil {
 				return err
 			}
-			matchedConfig = &config
-			break
 		}
-	}
-	if matchedConfig == nil {
-		return fmt.Errorf("no matching build_config found to build for %s", port.NameVersion())
-	}
 
-	// Tracking port depedencies infos.
-	for _, nameVersion := range matchedConfig.Depedencies {
-		var subPort Port
-		if err := subPort.Init(p.ctx, nameVersion); err != nil {
+		scheduler := newScheduler(p.ctx, graph, args.Silent(), args.KeepGoing())
+		if err := scheduler.run(); err != nil {
 			return err
 		}
 
-		if infos, ok := p.trackingPorts[subPort.Name]; ok {
-			contains := slices.ContainsFunc(infos, func(info trackingInfo) bool {
-				return info.version == subPort.Version
-			})
-			if !contains {
-				p.trackingPorts[subPort.Name] = append(p.trackingPorts[subPort.Name], trackingInfo{
-					version: subPort.Version,
-					parent:  port.Name,
-				})
+		// Record commits and checksums of installed ports.
+		if !args.Locked() {
+			if err := p.WriteLock(); err != nil {
+				return err
 			}
-		} else {
-			p.trackingPorts[subPort.Name] = append(p.trackingPorts[subPort.Name], trackingInfo{
-				version: subPort.Version,
-				parent:  port.Name,
-			})
 		}
 	}
 
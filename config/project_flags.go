@@ -0,0 +1,99 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// flagsBuildTypes are keys of BuildFlags, `common` is for all build types.
+var flagsBuildTypes = []string{"common", "release", "debug", "relwithdebinfo", "minsizerel"}
+
+// BuildFlags are compiler or linker flags of project, for example:
+// {"common": "-fno-omit-frame-pointer", "debug": "-fsanitize=address"}.
+type BuildFlags map[string]string
+
+// UnmarshalJSON normalizes keys to lower case, so that `Release` and `release` are the same build type.
+func (b *BuildFlags) UnmarshalJSON(data []byte) error {
+	var flags map[string]string
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return err
+	}
+	if flags == nil {
+		*b = nil
+		return nil
+	}
+
+	*b = make(BuildFlags)
+	for key, value := range flags {
+		normalized := strings.ToLower(strings.TrimSpace(key))
+		if _, ok := (*b)[normalized]; ok {
+			return fmt.Errorf("build type %q is defined more than once", normalized)
+		}
+		(*b)[normalized] = value
+	}
+	return nil
+}
+
+// Validate checks if keys of flags are `common` or build types.
+func (b BuildFlags) Validate() error {
+	for key := range b {
+		if !slices.Contains(flagsBuildTypes, key) {
+			return fmt.Errorf("unknown build type %q, it should be one of %s",
+				key, strings.Join(flagsBuildTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// Resolve returns common flags followed by flags of the build type.
+func (b BuildFlags) Resolve(buildType string) string {
+	var flags []string
+	if value := strings.TrimSpace(b.get("common")); value != "" {
+		flags = append(flags, value)
+	}
+	if value := strings.TrimSpace(b.get(buildType)); value != "" {
+		flags = append(flags, value)
+	}
+	return strings.Join(flags, " ")
+}
+
+// writeCMake appends flags to cmake variables like `CMAKE_C_FLAGS_INIT` and `CMAKE_C_FLAGS_DEBUG_INIT`,
+// since build type of consumer is only known by cmake.
+func (b BuildFlags) writeCMake(toolchain *strings.Builder, variables ...string) {
+	for _, buildType := range flagsBuildTypes {
+		value := strings.TrimSpace(b.get(buildType))
+		if value == "" {
+			continue
+		}
+
+		suffix := "_INIT"
+		if buildType != "common" {
+			suffix = "_" + strings.ToUpper(buildType) + "_INIT"
+		}
+		for _, variable := range variables {
+			toolchain.WriteString(fmt.Sprintf("string(APPEND %s%s \" %s\")\n", variable, suffix, value))
+		}
+	}
+}
+
+func (b BuildFlags) get(buildType string) string {
+	return b[strings.ToLower(buildType)]
+}
+
+// flagsHash returns a short hash of project flags for the build type,
+// it's empty when no flags defined, so that caches without flags are still shared.
+func (p Project) flagsHash(buildType string) string {
+	cflags := p.CFlags.Resolve(buildType)
+	cxxflags := p.CXXFlags.Resolve(buildType)
+	ldflags := p.LDFlags.Resolve(buildType)
+	if cflags == "" && cxxflags == "" && ldflags == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{cflags, cxxflags, ldflags}, "\n")))
+	return hex.EncodeToString(hash[:])[:8]
+}
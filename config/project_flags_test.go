@@ -0,0 +1,104 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildFlags(t *testing.T) {
+	var flags BuildFlags
+	if err := json.Unmarshal([]byte(`{"common": "-fno-omit-frame-pointer", "Debug": "-fsanitize=address"}`), &flags); err != nil {
+		t.Fatal(err)
+	}
+	if err := flags.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if resolved := flags.Resolve("Debug"); resolved != "-fno-omit-frame-pointer -fsanitize=address" {
+		t.Fatalf("unexpected debug flags: %q", resolved)
+	}
+	if resolved := flags.Resolve("Release"); resolved != "-fno-omit-frame-pointer" {
+		t.Fatalf("unexpected release flags: %q", resolved)
+	}
+
+	var toolchain strings.Builder
+	flags.writeCMake(&toolchain, "CMAKE_C_FLAGS")
+	expected := "string(APPEND CMAKE_C_FLAGS_INIT \" -fno-omit-frame-pointer\")\n" +
+		"string(APPEND CMAKE_C_FLAGS_DEBUG_INIT \" -fsanitize=address\")\n"
+	if toolchain.String() != expected {
+		t.Fatalf("expected toolchain:\n%s\nbut got:\n%s", expected, toolchain.String())
+	}
+
+	if err := (BuildFlags{"profile": "-pg"}).Validate(); err == nil {
+		t.Fatal("expected error of unknown build type, but got nil")
+	}
+
+	// Build types are case-insensitive, so the same one cannot be defined twice.
+	err := json.Unmarshal([]byte(`{"Release": "-O2", "release": "-O3"}`), &flags)
+	if err == nil || !strings.Contains(err.Error(), `build type "release" is defined more than once`) {
+		t.Fatalf("expected error of duplicated build type, but got %v", err)
+	}
+}
+
+func TestFlagsHash(t *testing.T) {
+	var project Project
+	if hash := project.flagsHash("Release"); hash != "" {
+		t.Fatalf("expected empty hash without flags, but got %q", hash)
+	}
+
+	project.CXXFlags = BuildFlags{"debug": "-fsanitize=address"}
+	if hash := project.flagsHash("Release"); hash != "" {
+		t.Fatalf("expected empty hash without release flags, but got %q", hash)
+	}
+
+	debugHash := project.flagsHash("Debug")
+	if len(debugHash) != 8 {
+		t.Fatalf("expected short hash, but got %q", debugHash)
+	}
+
+	project.LDFlags = BuildFlags{"common": "-Wl,--as-needed"}
+	if hash := project.flagsHash("Debug"); hash == debugHash {
+		t.Fatal("expected hash to be changed with ld_flags")
+	}
+}
+
+func TestFlagsChanged(t *testing.T) {
+	dirs := Dirs
+	Dirs.PortsDir, Dirs.InstalledDir = t.TempDir(), t.TempDir()
+	defer func() { Dirs = dirs }()
+	writeTestPort(t, "zlib@1.3.1", ``)
+
+	ctx := NewBuildEnv()
+	initPort := func() Port {
+		var port Port
+		if err := port.Init(ctx, "zlib@1.3.1"); err != nil {
+			t.Fatal(err)
+		}
+		return port
+	}
+
+	// Installed without project flags.
+	port := initPort()
+	if err := os.WriteFile(filepath.Join(Dirs.InstalledDir, "libz.a"), nil, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStateFile(port.stateFile, "source", "", []string{"libz.a"}); err != nil {
+		t.Fatal(err)
+	}
+	if !port.Installed() {
+		t.Fatal("expected port to be installed")
+	}
+
+	// Package and installed state are not reused when flags are changed.
+	ctx.project.CFlags = BuildFlags{"common": "-O3"}
+	changed := initPort()
+	if changed.Installed() {
+		t.Fatal("expected port to be installed again when flags are changed")
+	}
+	if changed.PackageDir() == port.PackageDir() {
+		t.Fatalf("expected package dir to be changed, but got %s", changed.PackageDir())
+	}
+}
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"buildenv/pkg/semver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxResolveRounds limits how many times the dependency graph would be walked
+// before the resolver gives up finding a stable version for every port.
+const maxResolveRounds = 16
+
+type requirement struct {
+	constraint string
+	parent     string
+}
+
+// splitNameVersion splits `name@version` or `name@constraint` into name and version, features are ignored.
+func splitNameVersion(nameVersion string) (name, version string, err error) {
+	name, version, _, err = splitPortRef(nameVersion)
+	return name, version, err
+}
+
+// splitPortRef splits `name@version[feature1,feature2]` into name, version and features,
+// version can also be a constraint like `>=1.2.11 <1.3`.
+func splitPortRef(nameVersion string) (name, version string, features []string, err error) {
+	index := strings.Index(nameVersion, "@")
+	if index <= 0 || index == len(nameVersion)-1 {
+		return "", "", nil, fmt.Errorf("port name and version are invalid %s", nameVersion)
+	}
+
+	name = strings.TrimSpace(nameVersion[:index])
+	version, features, err = splitFeatures(strings.TrimSpace(nameVersion[index+1:]))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("%s: %w", nameVersion, err)
+	}
+	if name == "" || version == "" || strings.Contains(version, "@") {
+		return "", "", nil, fmt.Errorf("port name and version are invalid %s", nameVersion)
+	}
+
+	return name, version, features, nil
+}
+
+// portVersions returns all versions of port that defined in `conf/ports/<name>`.
+func portVersions(name string) ([]string, error) {
+	entities, err := os.ReadDir(filepath.Join(Dirs.PortsDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []string
+	for _, entity := range entities {
+		if entity.IsDir() || !strings.HasSuffix(entity.Name(), ".json") {
+			continue
+		}
+
+		// Skip cmake config files, for example: `v1.2.3@cmake_config.json`.
+		if strings.HasSuffix(entity.Name(), "@cmake_config.json") {
+			continue
+		}
+		versions = append(versions, strings.TrimSuffix(entity.Name(), ".json"))
+	}
+
+	return versions, nil
+}
+
+// satisfies reports whether version is accepted by the constraint,
+// an exact version only accepts itself.
+func satisfies(version, constraint string) (bool, error) {
+	if version == constraint {
+		return true, nil
+	}
+	if !semver.IsConstraint(constraint) {
+		return false, nil
+	}
+
+	parsed, err := semver.ParseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	// Versions that are not semantic versions, like branch names, never match a range.
+	semVersion, err := semver.Parse(version)
+	if err != nil {
+		return false, nil
+	}
+
+	return parsed.Check(semVersion), nil
+}
+
+// sortVersions sorts versions from highest to lowest,
+// semantic versions are always in front of other versions.
+func sortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, erri := semver.Parse(versions[i])
+		vj, errj := semver.Parse(versions[j])
+
+		switch {
+		case erri == nil && errj == nil:
+			return vi.Compare(vj) > 0
+		case erri == nil:
+			return true
+		case errj == nil:
+			return false
+		default:
+			return versions[i] > versions[j]
+		}
+	})
+}
+
+// matchVersions returns versions of port that satisfy all the constraints, highest first.
+func matchVersions(name string, constraints ...string) ([]string, error) {
+	versions, err := portVersions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, version := range versions {
+		ok := true
+		for _, constraint := range constraints {
+			satisfied, err := satisfies(version, constraint)
+			if err != nil {
+				return nil, err
+			}
+			if !satisfied {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			matched = append(matched, version)
+		}
+	}
+
+	sortVersions(matched)
+	return matched, nil
+}
+
+// resolveVersion returns the version of port to use for the given constraint,
+// version resolved by project has the highest priority, otherwise the highest matched version.
+func resolveVersion(ctx Context, name, constraint string, asDev bool) (string, error) {
+	// Exact version always wins.
+	if !semver.IsConstraint(constraint) {
+		return constraint, nil
+	}
+
+	if _, err := semver.ParseConstraint(constraint); err != nil {
+		return "", err
+	}
+
+	if ctx != nil {
+		if version, ok := ctx.Project().resolvedVersions[resolveKey(name, asDev)]; ok {
+			satisfied, err := satisfies(version, constraint)
+			if err != nil {
+				return "", err
+			}
+			if satisfied {
+				return version, nil
+			}
+		}
+	}
+
+	matched, err := matchVersions(name, constraint)
+	if err != nil {
+		return "", err
+	}
+	if len(matched) == 0 {
+		return "", fmt.Errorf("no version of %s satisfies %s", name, constraint)
+	}
+
+	return matched[0], nil
+}
+
+// resolveKey makes dev ports and none-dev ports resolved separately,
+// since they are installed into different dirs.
+func resolveKey(name string, asDev bool) string {
+	if asDev {
+		return name + "^dev"
+	}
+	return name
+}
+
+// resolvePorts walks through the whole dependency graph and picks exactly one version
+// for every port that satisfies all constraints required by project and other ports.
+func (p *Project) resolvePorts() error {
+	clear(p.resolvedVersions)
+	clear(p.resolvedFeatures)
+
+	for round := 0; round < maxResolveRounds; round++ {
+		requirements, features, err := p.collectRequirements()
+		if err != nil {
+			return err
+		}
+
+		// Pick the highest version that satisfies all requirements.
+		var summaries []string
+		resolved := make(map[string]string)
+		for key, requires := range requirements {
+			name := strings.TrimSuffix(key, "^dev")
+
+			var constraints []string
+			for _, require := range requires {
+				constraints = append(constraints, require.constraint)
+			}
+
+			matched, err := matchVersions(name, constraints...)
+			if err != nil {
+				return err
+			}
+
+			// Version locked in buildenv.lock must still satisfy all requirements.
+			if version, ok := p.lockedVersion(key); ok {
+				if !slices.Contains(matched, version) {
+					return fmt.Errorf("buildenv.lock is out of date, please run with --update-lock:\n"+
+						"    - locked %s@%s no longer satisfies %s", name, version, strings.Join(constraints, ", "))
+				}
+				resolved[key] = version
+				continue
+			}
+
+			if len(matched) == 0 {
+				var conflicts []string
+				for _, require := range requires {
+					conflicts = append(conflicts, fmt.Sprintf("%s@%s is required by %s", name, require.constraint, require.parent))
+				}
+				summaries = append(summaries, fmt.Sprintf("    - %s", strings.Join(conflicts, ", ")))
+				continue
+			}
+			resolved[key] = matched[0]
+		}
+		if len(summaries) > 0 {
+			slices.Sort(summaries)
+			return fmt.Errorf("detected conflicting versions of ports:\n%s", strings.Join(summaries, "\n"))
+		}
+
+		// The graph is stable when no version or feature changed in this round,
+		// features requested by all parents are enabled together.
+		stable := len(resolved) == len(p.resolvedVersions) && len(features) == len(p.resolvedFeatures)
+		for key, version := range resolved {
+			if p.resolvedVersions[key] != version || !slices.Equal(p.resolvedFeatures[key], features[key]) {
+				stable = false
+				break
+			}
+		}
+		if stable {
+			return nil
+		}
+
+		clear(p.resolvedVersions)
+		for key, version := range resolved {
+			p.resolvedVersions[key] = version
+		}
+		clear(p.resolvedFeatures)
+		for key, items := range features {
+			p.resolvedFeatures[key] = items
+		}
+	}
+
+	return fmt.Errorf("failed to resolve versions of ports after %d rounds", maxResolveRounds)
+}
+
+// collectRequirements collects constraints and features of every port with the currently resolved versions.
+func (p *Project) collectRequirements() (map[string][]requirement, map[string][]string, error) {
+	requirements := make(map[string][]requirement)
+	features := make(map[string][]string)
+	visited := make(map[string]bool)
+
+	var collect func(nameVersion, parent string, asDev bool) error
+	collect = func(nameVersion, parent string, asDev bool) error {
+		name, constraint, requested, err := splitPortRef(nameVersion)
+		if err != nil {
+			return err
+		}
+
+		key := resolveKey(name, asDev)
+		if !slices.Contains(requirements[key], requirement{constraint, parent}) {
+			requirements[key] = append(requirements[key], requirement{constraint, parent})
+		}
+		if len(requested) > 0 {
+			features[key] = mergeFeatures(features[key], requested)
+		}
+
+		var port Port
+		port.AsDev = asDev
+		port.AsSubDep = parent != p.Name
+		if err := port.Init(p.ctx, nameVersion); err != nil {
+			return err
+		}
+
+		// Every port only need to be walked once.
+		if visited[key+"@"+port.folderName()] {
+			return nil
+		}
+		visited[key+"@"+port.folderName()] = true
+
+		// Ports without build configs have no dependencies.
+		if len(port.BuildConfigs) == 0 {
+			return nil
+		}
+		matchedConfig, err := port.MatchedConfig()
+		if err != nil {
+			return err
+		}
+
+		for _, dependency := range matchedConfig.DevDepedencies {
+			// Skip self.
+			if depName, _, err := splitNameVersion(dependency); err == nil && asDev && depName == port.Name {
+				continue
+			}
+			if err := collect(dependency, port.FullName(), true); err != nil {
+				return err
+			}
+		}
+		for _, dependency := range matchedConfig.Depedencies {
+			if err := collect(dependency, port.FullName(), asDev); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, nameVersion := range p.Ports {
+		if err := collect(nameVersion, p.Name, false); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return requirements, features, nil
+}
+
+// PortExists reports whether any version of port satisfies the `name@version` or `name@constraint`.
+func PortExists(nameVersion string) bool {
+	name, constraint, err := splitNameVersion(nameVersion)
+	if err != nil {
+		return false
+	}
+
+	matched, err := matchVersions(name, constraint)
+	return err == nil && len(matched) > 0
+}
@@ -12,8 +12,11 @@ import (
 
 type RootFS struct {
 	Url             string   `json:"url"`                    // Download url.
+	Urls            []string `json:"urls,omitempty"`         // Fallback urls tried in order when url is not accessible.
 	ArchiveName     string   `json:"archive_name,omitempty"` // Archive name can be changed to avoid conflict.
 	Path            string   `json:"path"`                   // Runtime path of tool, it's relative path  and would be converted to absolute path later.
+	Sha256          string   `json:"sha256,omitempty"`       // Optional sha256 of archive.
+	Sha512          string   `json:"sha512,omitempty"`       // Optional sha512 of archive.
 	ExtraHeaderDirs []string `json:"extra_header_dirs"`
 	ExtraLibDirs    []string `json:"extra_lib_dirs"`
 	PkgConfigPath   []string `json:"pkg_config_path"`
@@ -25,6 +28,7 @@ type RootFS struct {
 
 func (r *RootFS) Validate() error {
 	// Validate rootfs download url.
+	r.Url, r.Urls = primaryUrl(r.Url, r.Urls)
 	if r.Url == "" {
 		return fmt.Errorf("rootfs.url is empty")
 	}
@@ -85,6 +89,7 @@ func (r RootFS) CheckAndRepair(args SetupArgs) error {
 
 	// Check and repair resource.
 	repair := fileio.NewDownloadRepair(r.Url, archiveName, folderName, Dirs.ExtractedToolsDir, Dirs.DownloadedDir)
+	repair.SetFallbackUrls(r.Urls...).SetSha256(r.Sha256).SetSha512(r.Sha512)
 	if err := repair.CheckAndRepair(); err != nil {
 		return err
 	}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"buildenv/pkg/color"
+	"buildenv/pkg/env"
+	"buildenv/pkg/fileio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// scheduler builds independent ports of graph concurrently, every port is built in a child process,
+// since building a port changes environment variables and work dir of the process.
+// Jobs of every port are drawn from the global `job_num`, so that the machine won't be oversubscribed.
+type scheduler struct {
+	ctx       Context
+	graph     *Graph
+	silent    bool
+	keepGoing bool
+
+	// build installs port with given jobs, it's replaced in tests.
+	build func(node *GraphNode, jobNum int) ([]byte, error)
+}
+
+type scheduleResult struct {
+	node   *GraphNode
+	jobNum int
+	output []byte
+	err    error
+}
+
+func newScheduler(ctx Context, graph *Graph, silent, keepGoing bool) *scheduler {
+	s := &scheduler{
+		ctx:       ctx,
+		graph:     graph,
+		silent:    silent,
+		keepGoing: keepGoing,
+	}
+	s.build = s.buildInChild
+	return s
+}
+
+func (s scheduler) run() error {
+	const (
+		pending = iota
+		running
+		succeeded
+		failed
+		skipped
+	)
+
+	states := make(map[*GraphNode]int)
+	sources := make(map[string]bool) // Source dirs of running ports.
+	available := max(s.ctx.JobNum(), 1)
+	results := make(chan scheduleResult)
+
+	var (
+		runningNum int
+		stopped    bool
+		failures   []string
+	)
+
+	for {
+		// Collect ports that all dependencies are installed, in the order they are discovered.
+		var ready []*GraphNode
+		for _, node := range s.graph.Nodes {
+			if states[node] != pending {
+				continue
+			}
+
+			isReady := true
+			for _, dependency := range node.Dependencies {
+				switch states[dependency] {
+				case failed, skipped:
+					// Port would never be built when any of its dependencies failed.
+					states[node] = skipped
+					failures = append(failures, fmt.Sprintf("    - %s: skipped because %s is not installed",
+						node.Label(), dependency.Label()))
+					isReady = false
+				case succeeded:
+				default:
+					isReady = false
+				}
+				if !isReady {
+					break
+				}
+			}
+			if isReady {
+				ready = append(ready, node)
+			}
+		}
+
+		// No more port would be started after failure in fail-fast mode.
+		if stopped {
+			ready = nil
+		}
+
+		// Ports already installed need no child process.
+		var toBuild []*GraphNode
+		for _, node := range ready {
+			if node.Port.Installed() {
+				if err := node.Port.Install(s.silent); err != nil {
+					states[node] = failed
+					failures = append(failures, fmt.Sprintf("    - %s: %s", node.Label(), err))
+					stopped = !s.keepGoing
+					continue
+				}
+				states[node] = succeeded
+			} else {
+				toBuild = append(toBuild, node)
+			}
+		}
+		if len(toBuild) < len(ready) {
+			continue
+		}
+
+		// Dev and none-dev nodes of the same port share one source dir, they must be built one by one.
+		var toStart []*GraphNode
+		for _, node := range toBuild {
+			if !sources[node.Port.folderName()] && !slices.ContainsFunc(toStart, func(item *GraphNode) bool {
+				return item.Port.folderName() == node.Port.folderName()
+			}) {
+				toStart = append(toStart, node)
+			}
+		}
+
+		// Share available jobs with ready ports, the last one takes all the rest.
+		for index, node := range toStart {
+			if available == 0 {
+				break
+			}
+
+			jobNum := max(available/(len(toStart)-index), 1)
+			available -= jobNum
+			states[node] = running
+			sources[node.Port.folderName()] = true
+			runningNum++
+
+			go func(node *GraphNode, jobNum int) {
+				output, err := s.build(node, jobNum)
+				results <- scheduleResult{node: node, jobNum: jobNum, output: output, err: err}
+			}(node, jobNum)
+		}
+
+		if runningNum == 0 {
+			break
+		}
+
+		// Wait for any port finished, output of every port is printed as a whole.
+		result := <-results
+		runningNum--
+		available += result.jobNum
+		delete(sources, result.node.Port.folderName())
+
+		if result.err != nil {
+			states[result.node] = failed
+			failures = append(failures, fmt.Sprintf("    - %s: %s", result.node.Label(), result.err))
+			os.Stdout.Write(result.output)
+			stopped = !s.keepGoing
+		} else {
+			states[result.node] = succeeded
+			if !s.silent {
+				os.Stdout.Write(result.output)
+			}
+		}
+	}
+
+	// Ports left are those depend on failed ports or stopped by fail-fast.
+	for _, node := range s.graph.Nodes {
+		if states[node] == pending {
+			failures = append(failures, fmt.Sprintf("    - %s: not installed because of previous failures", node.Label()))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to install ports:\n%s", strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+// buildInChild installs port in a child process with `buildenv install`.
+func (s scheduler) buildInChild(node *GraphNode, jobNum int) ([]byte, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"install", node.Port.FullName(),
+		"-build_type=" + s.ctx.BuildType(),
+		fmt.Sprintf("-jobs=%d", jobNum),
+		"-locked",
+	}
+	if node.Dev {
+		args = append(args, "-dev")
+	}
+	if fileio.Offline() {
+		args = append(args, "-offline")
+	}
+
+	var buffer bytes.Buffer
+	title := color.Sprintf(color.Blue, "\n======== [%s] jobs: %d ========\n", node.Label(), jobNum)
+	buffer.WriteString(title)
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = Dirs.WorkspaceDir
+	// Child cleans environment again, original one is passed for placeholders like `${ENV:TOKEN}`.
+	cmd.Env = append(env.Original(), os.Environ()...)
+	cmd.Stdout = &buffer
+	cmd.Stderr = &buffer
+
+	if err := cmd.Run(); err != nil {
+		return buffer.Bytes(), err
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testScheduler builds ports in process, ports in `failures` fail when they're built.
+type testScheduler struct {
+	mutex    sync.Mutex
+	built    []string
+	building map[string]int
+	overlap  bool
+}
+
+func (t *testScheduler) build(failures ...string) func(node *GraphNode, jobNum int) ([]byte, error) {
+	return func(node *GraphNode, jobNum int) ([]byte, error) {
+		folder := node.Port.folderName()
+
+		t.mutex.Lock()
+		t.building[folder]++
+		if t.building[folder] > 1 {
+			t.overlap = true
+		}
+		t.mutex.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.building[folder]--
+		t.built = append(t.built, node.Label())
+
+		if slices.Contains(failures, node.Port.FullName()) {
+			return nil, fmt.Errorf("build failed")
+		}
+		return nil, nil
+	}
+}
+
+func newTestNode(nameVersion string, dev bool, dependencies ...*GraphNode) *GraphNode {
+	name, version, _ := strings.Cut(nameVersion, "@")
+	return &GraphNode{
+		Port:         Port{Name: name, Version: version},
+		Dev:          dev,
+		Dependencies: dependencies,
+	}
+}
+
+func runTestScheduler(jobNum int, keepGoing bool, nodes []*GraphNode, failures ...string) (*testScheduler, error) {
+	tester := testScheduler{building: make(map[string]int)}
+	scheduler := newScheduler(NewBuildEnv().SetJobNum(jobNum), &Graph{Nodes: nodes}, true, keepGoing)
+	scheduler.build = tester.build(failures...)
+	return &tester, scheduler.run()
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	c := newTestNode("c@3", false)
+	b := newTestNode("b@2", false, c)
+	a := newTestNode("a@1", false, b, c)
+
+	tester, err := runTestScheduler(4, false, []*GraphNode{a, b, c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"c@3", "b@2", "a@1"}; !slices.Equal(tester.built, expected) {
+		t.Fatalf("expected %v but got %v", expected, tester.built)
+	}
+}
+
+func TestSchedulerFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepGoing bool
+		built     []string
+		messages  []string
+	}{
+		{
+			name:  "fail fast",
+			built: []string{"b@2"},
+			messages: []string{
+				"b@2: build failed",
+				"a@1: skipped because b@2 is not installed",
+				"d@4: not installed because of previous failures",
+			},
+		},
+		{
+			name:      "keep going",
+			keepGoing: true,
+			built:     []string{"b@2", "d@4"},
+			messages: []string{
+				"b@2: build failed",
+				"a@1: skipped because b@2 is not installed",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Only one job, so that ports are built in the order they're discovered.
+			b := newTestNode("b@2", false)
+			a := newTestNode("a@1", false, b)
+			d := newTestNode("d@4", false)
+
+			tester, err := runTestScheduler(1, test.keepGoing, []*GraphNode{a, b, d}, "b@2")
+			if err == nil {
+				t.Fatal("expected failure")
+			}
+			if !slices.Equal(tester.built, test.built) {
+				t.Fatalf("expected %v built but got %v", test.built, tester.built)
+			}
+			for _, message := range test.messages {
+				if !strings.Contains(err.Error(), message) {
+					t.Fatalf("expected %q in error:\n%s", message, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSchedulerSharedSource(t *testing.T) {
+	// Dev and none-dev nodes of the same port share one source dir.
+	cmake := newTestNode("cmake@3.30.5", true)
+	zlibDev := newTestNode("zlib@1.3.1", true)
+	zlib := newTestNode("zlib@1.3.1", false)
+
+	tester, err := runTestScheduler(4, false, []*GraphNode{zlib, zlibDev, cmake})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tester.overlap {
+		t.Fatal("ports sharing source dir are built at the same time")
+	}
+	if len(tester.built) != 3 {
+		t.Fatalf("expected 3 ports built but got %v", tester.built)
+	}
+}
@@ -5,6 +5,10 @@ type SetupArgs interface {
 	BuildType() string
 	RepairBuildenv() bool
 	InstallPorts() bool
+	UpdateLock() bool
+	Locked() bool
+	KeepGoing() bool
+	Offline() bool
 }
 
 type setupArgs struct {
@@ -12,6 +16,10 @@ type setupArgs struct {
 	buildType      string // CMAKE_BUILD_TYPE, default is 'Release'
 	repairBuildenv bool   // Called to check and fix build environment.
 	installPorts   bool   // Called to install a 3rd party ports.
+	updateLock     bool   // Called to resolve ports again and rewrite buildenv.lock.
+	locked         bool   // Use buildenv.lock as it is and never update it.
+	keepGoing      bool   // Keep building other ports when some port failed.
+	offline        bool   // Use local downloads, repos and caches only.
 }
 
 func (s setupArgs) Silent() bool {
@@ -40,6 +48,42 @@ func (s *setupArgs) SetInstallPorts(installPort bool) *setupArgs {
 	return s
 }
 
+func (s setupArgs) UpdateLock() bool {
+	return s.updateLock
+}
+
+func (s *setupArgs) SetUpdateLock(updateLock bool) *setupArgs {
+	s.updateLock = updateLock
+	return s
+}
+
+func (s setupArgs) Locked() bool {
+	return s.locked
+}
+
+func (s *setupArgs) SetLocked(locked bool) *setupArgs {
+	s.locked = locked
+	return s
+}
+
+func (s setupArgs) KeepGoing() bool {
+	return s.keepGoing
+}
+
+func (s *setupArgs) SetKeepGoing(keepGoing bool) *setupArgs {
+	s.keepGoing = keepGoing
+	return s
+}
+
+func (s setupArgs) Offline() bool {
+	return s.offline
+}
+
+func (s *setupArgs) SetOffline(offline bool) *setupArgs {
+	s.offline = offline
+	return s
+}
+
 func NewSetupArgs(silent, repairBuildenv, installPorts bool) *setupArgs {
 	return &setupArgs{
 		silent:         silent,
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// stateFileHeader is the prefix of header lines in state file, like `# from: source`.
+const stateFileHeader = "# "
+
+// errInvalidStateFile is returned when state file is not named like installed port.
+var errInvalidStateFile = errors.New("invalid state file name")
+
+// stateFileSeparator separates installed file and its sha256 in state file.
+const stateFileSeparator = "\t"
+
+// StateFile is the parsed `installed/buildenv/info/*.list`, it's named like
+// `name@version^platform^project^buildtype.list` or `name@version^dev.list` for dev port.
+type StateFile struct {
+	Name      string
+	Version   string
+	Features  []string
+	Platform  string
+	Project   string
+	BuildType string
+	Dev       bool
+	From      string            // Where port was installed from, it's empty for state files written by old buildenv.
+	Flags     string            // Hash of project flags that port was built with, it's empty without flags.
+	Files     []string          // Installed files relative to `installed` dir.
+	Checksums map[string]string // Sha256 of installed files, it's empty for state files written by old buildenv.
+}
+
+// InstalledPath returns full path of installed file, files of dev port are relative to `installed/dev`.
+func (s StateFile) InstalledPath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	if s.Dev && !strings.HasPrefix(file, "dev/") {
+		return filepath.Join(Dirs.InstalledDir, "dev", file)
+	}
+	return filepath.Join(Dirs.InstalledDir, file)
+}
+
+// Size returns total size of installed files that still exist.
+func (s StateFile) Size() int64 {
+	var size int64
+	for _, file := range s.Files {
+		if info, err := os.Stat(s.InstalledPath(file)); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// ReadStateFile parses state file with its name and content.
+func ReadStateFile(path string) (*StateFile, error) {
+	fileName := strings.TrimSuffix(filepath.Base(path), ".list")
+	parts := strings.Split(fileName, "^")
+
+	var state StateFile
+	switch {
+	case len(parts) == 2 && parts[1] == "dev":
+		state.Dev = true
+	case len(parts) == 4:
+		state.Platform = parts[1]
+		state.Project = parts[2]
+		state.BuildType = parts[3]
+	default:
+		return nil, fmt.Errorf("%w: %s", errInvalidStateFile, filepath.Base(path))
+	}
+
+	// Folder name is like `name@version~feat1~feat2`, version may contain `+` like `1.0.0+build.1`.
+	nameVersion := strings.Split(parts[0], featureSeparator)
+	name, version, err := splitNameVersion(nameVersion[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidStateFile, filepath.Base(path))
+	}
+	state.Name = name
+	state.Version = version
+	state.Features = nameVersion[1:]
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, stateFileHeader) {
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, stateFileHeader), ":")
+			if !ok {
+				continue
+			}
+			switch strings.TrimSpace(key) {
+			case "from":
+				state.From = strings.TrimSpace(value)
+			case "flags":
+				state.Flags = strings.TrimSpace(value)
+			}
+			continue
+		}
+
+		// File may be followed by its sha256, like `include/zlib.h	<sha256>`.
+		file, checksum, ok := strings.Cut(line, stateFileSeparator)
+		file = strings.TrimSpace(file)
+		state.Files = append(state.Files, file)
+		if ok {
+			if state.Checksums == nil {
+				state.Checksums = make(map[string]string)
+			}
+			state.Checksums[file] = strings.TrimSpace(checksum)
+		}
+	}
+
+	return &state, nil
+}
+
+// ListStateFiles returns all installed ports sorted by name, state files with invalid name are skipped with warning.
+func ListStateFiles() ([]StateFile, error) {
+	infoDir := filepath.Join(Dirs.InstalledDir, "buildenv", "info")
+	if !fileio.PathExists(infoDir) {
+		return nil, nil
+	}
+
+	entities, err := os.ReadDir(infoDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []StateFile
+	for _, entity := range entities {
+		if entity.IsDir() || !strings.HasSuffix(entity.Name(), ".list") {
+			continue
+		}
+
+		state, err := ReadStateFile(filepath.Join(infoDir, entity.Name()))
+		if err != nil {
+			if errors.Is(err, errInvalidStateFile) {
+				PrintWarning("%s is skipped: %s", entity.Name(), err)
+				continue
+			}
+			return nil, err
+		}
+		states = append(states, *state)
+	}
+
+	slices.SortFunc(states, func(a, b StateFile) int {
+		return strings.Compare(a.Name+"@"+a.Version, b.Name+"@"+b.Version)
+	})
+	return states, nil
+}
+
+// writeStateFile writes where port was installed from and hash of project flags as header,
+// and installed files with their sha256 line by line.
+func writeStateFile(path, from, flags string, files []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	state := StateFile{Dev: strings.HasSuffix(path, "^dev.list")}
+
+	var lines []string
+	lines = append(lines, stateFileHeader+"from: "+from)
+	if flags != "" {
+		lines = append(lines, stateFileHeader+"flags: "+flags)
+	}
+	for _, file := range files {
+		// Checksum is skipped for files that cannot be read, like broken symlinks.
+		checksum, err := fileio.FileSha256(state.InstalledPath(file))
+		if err != nil {
+			lines = append(lines, file)
+			continue
+		}
+		lines = append(lines, file+stateFileSeparator+checksum)
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), os.ModePerm)
+}
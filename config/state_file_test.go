@@ -0,0 +1,51 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStateFile(t *testing.T) {
+	installedDir := Dirs.InstalledDir
+	Dirs.InstalledDir = t.TempDir()
+	defer func() { Dirs.InstalledDir = installedDir }()
+
+	infoDir := filepath.Join(Dirs.InstalledDir, "buildenv", "info")
+	files := []string{"x86_64-linux^demo^Release/include/curl/curl.h", "x86_64-linux^demo^Release/lib/libcurl.a"}
+	if err := writeStateFile(filepath.Join(infoDir, "curl@8.5.0+build.1~http2~ssl^x86_64-linux^demo^Release.list"), "source", "", files); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStateFile(filepath.Join(infoDir, "cmake@3.30.5^dev.list"), "archive", "", []string{"bin/cmake"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// State file with invalid name is skipped.
+	if err := writeStateFile(filepath.Join(infoDir, "unknown^Release.list"), "source", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := ListStateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("expected 2 installed ports but got %d", len(states))
+	}
+
+	cmake := states[0]
+	if cmake.Name != "cmake" || cmake.Version != "3.30.5" || !cmake.Dev || cmake.From != "archive" || len(cmake.Files) != 1 {
+		t.Fatalf("unexpected dev state file: %+v", cmake)
+	}
+
+	curl := states[1]
+	if curl.Name != "curl" || curl.Version != "8.5.0+build.1" || curl.Dev || curl.From != "source" {
+		t.Fatalf("unexpected state file: %+v", curl)
+	}
+	if curl.Platform != "x86_64-linux" || curl.Project != "demo" || curl.BuildType != "Release" {
+		t.Fatalf("unexpected state file: %+v", curl)
+	}
+	if !slices.Equal(curl.Features, []string{"http2", "ssl"}) || !slices.Equal(curl.Files, files) {
+		t.Fatalf("unexpected state file: %+v", curl)
+	}
+}
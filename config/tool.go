@@ -11,9 +11,12 @@ import (
 )
 
 type Tool struct {
-	Url         string `json:"url"`          // Download url.
-	ArchiveName string `json:"archive_name"` // Archive name can be changed to avoid conflict.
-	Path        string `json:"path"`         // Runtime path of tool, it's relative path  and would be converted to absolute path later.
+	Url         string   `json:"url"`              // Download url.
+	Urls        []string `json:"urls,omitempty"`   // Fallback urls tried in order when url is not accessible.
+	ArchiveName string   `json:"archive_name"`     // Archive name can be changed to avoid conflict.
+	Path        string   `json:"path"`             // Runtime path of tool, it's relative path  and would be converted to absolute path later.
+	Sha256      string   `json:"sha256,omitempty"` // Optional sha256 of archive.
+	Sha512      string   `json:"sha512,omitempty"` // Optional sha512 of archive.
 
 	// Internal fields.
 	toolName  string `json:"-"`
@@ -43,6 +46,7 @@ func (t *Tool) Init(toolpath string) error {
 
 func (t *Tool) Validate() error {
 	// Validate tool download url.
+	t.Url, t.Urls = primaryUrl(t.Url, t.Urls)
 	if t.Url == "" {
 		return fmt.Errorf("url of %s is empty", t.toolName)
 	}
@@ -92,6 +96,7 @@ func (t Tool) CheckAndRepair(args SetupArgs) error {
 
 	// Check and repair resource.
 	repair := fileio.NewDownloadRepair(t.Url, archiveName, folderName, Dirs.ExtractedToolsDir, Dirs.DownloadedDir)
+	repair.SetFallbackUrls(t.Urls...).SetSha256(t.Sha256).SetSha512(t.Sha512)
 	if err := repair.CheckAndRepair(); err != nil {
 		return err
 	}
@@ -11,22 +11,25 @@ import (
 )
 
 type Toolchain struct {
-	Url             string `json:"url"`                    // Download url or local file url.
-	ArchiveName     string `json:"archive_name,omitempty"` // Archive name can be changed to avoid conflict.
-	Path            string `json:"path"`                   // Runtime path of tool, it's relative path and would be converted to absolute path later.
-	SystemName      string `json:"system_name"`            // It would be "Windows", "Linux", "Android" and so on.
-	SystemProcessor string `json:"system_processor"`       // It would be "x86_64", "aarch64" and so on.
-	Host            string `json:"host"`                   // It would be "x86_64-linux-gnu", "aarch64-linux-gnu" and so on.
-	ToolchainPrefix string `json:"toolchain_prefix"`       // It would be like "x86_64-linux-gnu-"
-	CC              string `json:"cc"`
-	CXX             string `json:"cxx"`
-	FC              string `json:"fc"`
-	RANLIB          string `json:"ranlib"`
-	AR              string `json:"ar"`
-	LD              string `json:"ld"`
-	NM              string `json:"nm"`
-	OBJDUMP         string `json:"objdump"`
-	STRIP           string `json:"strip"`
+	Url             string   `json:"url"`                    // Download url or local file url.
+	Urls            []string `json:"urls,omitempty"`         // Fallback urls tried in order when url is not accessible.
+	ArchiveName     string   `json:"archive_name,omitempty"` // Archive name can be changed to avoid conflict.
+	Path            string   `json:"path"`                   // Runtime path of tool, it's relative path and would be converted to absolute path later.
+	Sha256          string   `json:"sha256,omitempty"`       // Optional sha256 of archive.
+	Sha512          string   `json:"sha512,omitempty"`       // Optional sha512 of archive.
+	SystemName      string   `json:"system_name"`            // It would be "Windows", "Linux", "Android" and so on.
+	SystemProcessor string   `json:"system_processor"`       // It would be "x86_64", "aarch64" and so on.
+	Host            string   `json:"host"`                   // It would be "x86_64-linux-gnu", "aarch64-linux-gnu" and so on.
+	ToolchainPrefix string   `json:"toolchain_prefix"`       // It would be like "x86_64-linux-gnu-"
+	CC              string   `json:"cc"`
+	CXX             string   `json:"cxx"`
+	FC              string   `json:"fc"`
+	RANLIB          string   `json:"ranlib"`
+	AR              string   `json:"ar"`
+	LD              string   `json:"ld"`
+	NM              string   `json:"nm"`
+	OBJDUMP         string   `json:"objdump"`
+	STRIP           string   `json:"strip"`
 
 	// Internal fields.
 	fullpath  string `json:"-"`
@@ -35,6 +38,7 @@ type Toolchain struct {
 
 func (t *Toolchain) Validate() error {
 	// Validate toolchain download url.
+	t.Url, t.Urls = primaryUrl(t.Url, t.Urls)
 	if t.Url == "" {
 		return fmt.Errorf("toolchain.url would be http url or local file url, but it's empty")
 	}
@@ -140,6 +144,7 @@ func (t Toolchain) CheckAndRepair(args SetupArgs) error {
 
 	// Check and repair resource.
 	repair := fileio.NewDownloadRepair(t.Url, archiveName, folderName, Dirs.ExtractedToolsDir, Dirs.DownloadedDir)
+	repair.SetFallbackUrls(t.Urls...).SetSha256(t.Sha256).SetSha512(t.Sha512)
 	if err := repair.CheckAndRepair(); err != nil {
 		return err
 	}
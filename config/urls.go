@@ -0,0 +1,12 @@
+package config
+
+import "strings"
+
+// primaryUrl returns url and its fallback urls, the first of urls would be the url when url is empty,
+// so that resource can be defined with `urls` only.
+func primaryUrl(url string, urls []string) (string, []string) {
+	if strings.TrimSpace(url) == "" && len(urls) > 0 {
+		return urls[0], urls[1:]
+	}
+	return url, urls
+}
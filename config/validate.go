@@ -0,0 +1,532 @@
+package config
+
+import (
+	"buildenv/buildsystem"
+	"buildenv/generator"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var envVarKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// cmakeConfigRefers are the sections can be referred by `cmake_config` of build_config.
+var cmakeConfigRefers = []string{"linux_static", "linux_shared", "windows_static", "windows_shared"}
+
+// Issue is a problem found in conf repo, line and column are 0 when it cannot be located.
+type Issue struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (i Issue) String() string {
+	file, err := filepath.Rel(Dirs.WorkspaceDir, i.File)
+	if err != nil {
+		file = i.File
+	}
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", file, i.Line, i.Column, i.Message)
+	}
+	return fmt.Sprintf("%s: %s", file, i.Message)
+}
+
+// confValidator collects issues of files in conf repo.
+type confValidator struct {
+	issues []Issue
+}
+
+// ValidateConfRepo loads every platform, project, tool and port in conf repo and returns all issues found.
+func ValidateConfRepo() ([]Issue, error) {
+	var validator confValidator
+
+	if err := validator.walk(Dirs.PlatformsDir, validator.validatePlatform); err != nil {
+		return nil, err
+	}
+	if err := validator.walk(Dirs.ProjectsDir, validator.validateProject); err != nil {
+		return nil, err
+	}
+	if err := validator.walk(Dirs.ToolsDir, validator.validateTool); err != nil {
+		return nil, err
+	}
+	if err := validator.walk(Dirs.PortsDir, validator.validatePortFile); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(validator.issues, func(i, j int) bool {
+		if validator.issues[i].File != validator.issues[j].File {
+			return validator.issues[i].File < validator.issues[j].File
+		}
+		return validator.issues[i].Line < validator.issues[j].Line
+	})
+	return validator.issues, nil
+}
+
+func (c *confValidator) walk(dir string, validate func(path string, data []byte)) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		validate(path, data)
+		return nil
+	})
+}
+
+func (c *confValidator) report(path string, data []byte, value, format string, args ...any) {
+	issue := Issue{File: path, Message: fmt.Sprintf(format, args...)}
+	if value != "" {
+		issue.Line, issue.Column = locate(data, value)
+	}
+	c.issues = append(c.issues, issue)
+}
+
+func (c *confValidator) validatePlatform(path string, data []byte) {
+	var platform Platform
+	if !c.decode(path, data, &platform) {
+		return
+	}
+
+	if platform.Toolchain != nil && platform.Toolchain.Url == "" && len(platform.Toolchain.Urls) == 0 {
+		c.report(path, data, `"toolchain"`, "toolchain.url is empty")
+	}
+	if platform.RootFS != nil && platform.RootFS.Url == "" && len(platform.RootFS.Urls) == 0 {
+		c.report(path, data, `"rootfs"`, "rootfs.url is empty")
+	}
+	for _, tool := range platform.Tools {
+		if _, err := os.Stat(filepath.Join(Dirs.ToolsDir, tool+".json")); err != nil {
+			c.report(path, data, quote(tool), "tool %s does not exist", tool)
+		}
+	}
+}
+
+func (c *confValidator) validateProject(path string, data []byte) {
+	var project Project
+	if !c.decode(path, data, &project) {
+		return
+	}
+
+	c.validateEnvVars(path, data, project.EnvVars)
+	c.validateVars(path, data, project.Vars)
+	c.validateFlags(path, data, "c_flags", project.CFlags)
+	c.validateFlags(path, data, "cxx_flags", project.CXXFlags)
+	c.validateFlags(path, data, "ld_flags", project.LDFlags)
+	if err := validateAllowOverwrite(project.AllowOverwrite); err != nil {
+		c.report(path, data, quote("allow_overwrite"), "allow_overwrite: %s", err)
+	}
+	for _, nameVersion := range project.Ports {
+		c.validateDependency(path, data, nameVersion)
+	}
+	for nameVersion, config := range project.OverridePorts {
+		c.validateDependency(path, data, nameVersion)
+		c.validateEnvVars(path, data, config.EnvVars)
+		for _, dependency := range config.Depedencies {
+			c.validateDependency(path, data, dependency)
+		}
+		for _, dependency := range config.DevDepedencies {
+			c.validateDependency(path, data, dependency)
+		}
+	}
+}
+
+func (c *confValidator) validateTool(path string, data []byte) {
+	var tool Tool
+	if !c.decode(path, data, &tool) {
+		return
+	}
+
+	if tool.Url == "" && len(tool.Urls) == 0 {
+		c.report(path, data, "", "url is empty")
+	}
+	if tool.Path == "" {
+		c.report(path, data, "", "path is empty")
+	}
+}
+
+func (c *confValidator) validatePortFile(path string, data []byte) {
+	name := filepath.Base(filepath.Dir(path))
+	version := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	// Validate cmake config file.
+	if strings.HasSuffix(version, "@cmake_config") {
+		var cmakeConfigs generator.CMakeConfigs
+		c.decode(path, data, &cmakeConfigs)
+		return
+	}
+
+	// Keys with `+` suffix append list of base port, so they're also valid.
+	var port Port
+	if !c.decode(path, data, &port) {
+		return
+	}
+
+	// Validate port merged with its base port.
+	merged, err := readPortFile(name, version)
+	if err != nil {
+		c.report(path, data, `"extends"`, "%s", err)
+		return
+	}
+	port = Port{}
+	if err := json.Unmarshal(merged, &port); err != nil {
+		c.report(path, data, "", "%s", err)
+		return
+	}
+
+	if port.Url == "" && len(port.Urls) == 0 {
+		c.report(path, data, "", "url is empty")
+	}
+
+	for index, config := range port.BuildConfigs {
+		c.validateBuildConfig(path, data, name, version, config)
+
+		// First matched build_config wins, so build_config would never be used when shadowed by previous one.
+		for _, previous := range port.BuildConfigs[:index] {
+			if patternShadows(previous.Pattern, config.Pattern) {
+				c.report(path, data, patternText(config.Pattern),
+					"build_config with pattern %s is unreachable, it's shadowed by pattern %s",
+					patternText(config.Pattern), patternText(previous.Pattern))
+				break
+			}
+		}
+	}
+
+	for featureName, feature := range port.Features {
+		c.validateEnvVars(path, data, feature.EnvVars)
+		c.validatePatches(path, data, name, feature.Patches)
+		for _, dependency := range feature.Depedencies {
+			c.validateDependency(path, data, dependency)
+		}
+		for _, dependency := range feature.DevDepedencies {
+			c.validateDependency(path, data, dependency)
+		}
+		if !validFeatureName(featureName) {
+			c.report(path, data, quote(featureName), "feature name %s is invalid", featureName)
+		}
+	}
+}
+
+func (c *confValidator) validateBuildConfig(path string, data []byte, name, version string, config buildsystem.BuildConfig) {
+	if err := config.Validate(); err != nil {
+		c.report(path, data, patternText(config.Pattern), "%s", err)
+	}
+
+	c.validateEnvVars(path, data, config.EnvVars)
+	c.validatePatches(path, data, name, config.Patches)
+	for _, dependency := range config.Depedencies {
+		c.validateDependency(path, data, dependency)
+	}
+	for _, dependency := range config.DevDepedencies {
+		c.validateDependency(path, data, dependency)
+	}
+
+	// Validate cmake_config refers to an existing section of `<version>@cmake_config.json`.
+	refer := strings.TrimSpace(config.CMakeConfig)
+	if refer == "" || strings.HasPrefix(refer, "//") {
+		return
+	}
+	if !slices.Contains(cmakeConfigRefers, refer) {
+		c.report(path, data, quote(config.CMakeConfig), "cmake_config %s is invalid, it should be one of %s",
+			refer, strings.Join(cmakeConfigRefers, ", "))
+		return
+	}
+	configPath := filepath.Join(Dirs.PortsDir, name, version+"@cmake_config.json")
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		c.report(path, data, quote(config.CMakeConfig), "cmake_config %s is referred but %s@cmake_config.json does not exist", refer, version)
+		return
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(configData, &sections); err == nil {
+		if _, ok := sections[refer]; !ok {
+			c.report(path, data, quote(config.CMakeConfig), "cmake_config %s is not defined in %s@cmake_config.json", refer, version)
+		}
+	}
+}
+
+func (c *confValidator) validatePatches(path string, data []byte, name string, patches []string) {
+	for _, patch := range patches {
+		patch = strings.TrimSpace(patch)
+		if patch == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(Dirs.PortsDir, name, patch)); err != nil {
+			c.report(path, data, quote(patch), "patch file %s does not exist", patch)
+		}
+	}
+}
+
+func (c *confValidator) validateEnvVars(path string, data []byte, envVars []string) {
+	for _, envVar := range envVars {
+		key, _, ok := strings.Cut(strings.TrimSpace(envVar), "=")
+		if !ok || !envVarKeyRegex.MatchString(strings.TrimSpace(key)) {
+			c.report(path, data, quote(envVar), "env var %q is invalid, it should be like KEY=VALUE", envVar)
+		}
+	}
+}
+
+func (c *confValidator) validateVars(path string, data []byte, vars []string) {
+	for _, item := range vars {
+		key, _, ok := strings.Cut(item, "=")
+		if !ok || !envVarKeyRegex.MatchString(strings.TrimSpace(key)) {
+			c.report(path, data, quote(item), "var %q is invalid, it should be like KEY=VALUE", item)
+		}
+	}
+}
+
+func (c *confValidator) validateFlags(path string, data []byte, name string, flags BuildFlags) {
+	if err := flags.Validate(); err != nil {
+		c.report(path, data, quote(name), "%s: %s", name, err)
+	}
+}
+
+func (c *confValidator) validateDependency(path string, data []byte, nameVersion string) {
+	name, constraint, _, err := splitPortRef(nameVersion)
+	if err != nil {
+		c.report(path, data, quote(nameVersion), "%s", err)
+		return
+	}
+
+	versions, err := portVersions(name)
+	if err != nil || len(versions) == 0 {
+		c.report(path, data, quote(nameVersion), "port %s does not exist", name)
+		return
+	}
+	matched, err := matchVersions(name, constraint)
+	if err != nil {
+		c.report(path, data, quote(nameVersion), "%s", err)
+		return
+	}
+	if len(matched) == 0 {
+		c.report(path, data, quote(nameVersion), "no version of %s satisfies %s", name, constraint)
+	}
+}
+
+// decode reports syntax error and unknown keys, it returns false when content cannot be decoded.
+func (c *confValidator) decode(path string, data []byte, value any) bool {
+	if err := json.Unmarshal(data, value); err != nil {
+		issue := Issue{File: path, Message: err.Error()}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			issue.Line, issue.Column = position(data, int(syntaxErr.Offset))
+		case errors.As(err, &typeErr):
+			issue.Line, issue.Column = position(data, int(typeErr.Offset))
+		}
+		c.issues = append(c.issues, issue)
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for _, offset := range unknownKeys(decoder, data, reflect.TypeOf(value).Elem()) {
+		line, column := position(data, offset.offset)
+		c.issues = append(c.issues, Issue{
+			File:    path,
+			Line:    line,
+			Column:  column,
+			Message: fmt.Sprintf("unknown key %q", offset.key),
+		})
+	}
+	return true
+}
+
+type keyOffset struct {
+	key    string
+	offset int
+}
+
+// unknownKeys walks json tokens along with the type, and returns keys not defined in struct.
+func unknownKeys(decoder *json.Decoder, data []byte, typ reflect.Type) []keyOffset {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	var unknowns []keyOffset
+	switch delim {
+	case '[':
+		var elemType reflect.Type
+		if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+			elemType = typ.Elem()
+		}
+		for decoder.More() {
+			unknowns = append(unknowns, unknownValue(decoder, data, elemType)...)
+		}
+
+	case '{':
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return unknowns
+			}
+			key, _ := token.(string)
+			keyEnd := int(decoder.InputOffset())
+
+			var fieldType reflect.Type
+			switch typ.Kind() {
+			case reflect.Map:
+				fieldType = typ.Elem()
+			case reflect.Struct:
+				fieldType = jsonFieldType(typ, key)
+				if fieldType == nil {
+					unknowns = append(unknowns, keyOffset{key: key, offset: bytes.LastIndexByte(data[:keyEnd-1], '"')})
+				}
+			}
+			unknowns = append(unknowns, unknownValue(decoder, data, fieldType)...)
+		}
+	}
+
+	// Consume the closing delim.
+	decoder.Token()
+	return unknowns
+}
+
+// unknownValue checks value of unknown type by skipping it.
+func unknownValue(decoder *json.Decoder, data []byte, typ reflect.Type) []keyOffset {
+	if typ == nil || (typ.Kind() != reflect.Struct && typ.Kind() != reflect.Map &&
+		typ.Kind() != reflect.Slice && typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Array) {
+		var skipped json.RawMessage
+		decoder.Decode(&skipped)
+		return nil
+	}
+	return unknownKeys(decoder, data, typ)
+}
+
+// jsonFieldType returns type of struct field with json key, list field suffixed with `+` is also accepted.
+func jsonFieldType(typ reflect.Type, key string) reflect.Type {
+	for index := 0; index < typ.NumField(); index++ {
+		field := typ.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		// Fields of embedded struct are promoted.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if fieldType := jsonFieldType(field.Type, key); fieldType != nil {
+				return fieldType
+			}
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if name == key {
+			return field.Type
+		}
+		if name+appendSuffix == key && field.Type.Kind() == reflect.Slice {
+			return field.Type
+		}
+	}
+
+	return nil
+}
+
+// patternShadows reports whether all platforms matched by pattern are also matched by previous one,
+// structured patterns are only compared as a whole.
+func patternShadows(previousPattern, currentPattern buildsystem.Pattern) bool {
+	if previousPattern.MatchAll() || previousPattern.String() == currentPattern.String() {
+		return true
+	}
+	if previousPattern.IsStructured() || currentPattern.IsStructured() {
+		return false
+	}
+
+	previous := strings.TrimSpace(previousPattern.Name)
+	pattern := strings.TrimSpace(currentPattern.Name)
+
+	kind := func(pattern string) (string, string) {
+		switch {
+		case pattern == "" || pattern == "*":
+			return "all", ""
+		case len(pattern) > 1 && pattern[0] == '*' && pattern[len(pattern)-1] == '*':
+			return "contains", pattern[1 : len(pattern)-1]
+		case pattern[0] == '*':
+			return "suffix", pattern[1:]
+		case pattern[len(pattern)-1] == '*':
+			return "prefix", pattern[:len(pattern)-1]
+		default:
+			return "exact", pattern
+		}
+	}
+
+	previousKind, previousText := kind(previous)
+	patternKind, patternText := kind(pattern)
+	if patternKind == "all" {
+		return false
+	}
+
+	switch previousKind {
+	case "contains":
+		return strings.Contains(patternText, previousText)
+	case "prefix":
+		return (patternKind == "exact" || patternKind == "prefix") && strings.HasPrefix(patternText, previousText)
+	case "suffix":
+		return (patternKind == "exact" || patternKind == "suffix") && strings.HasSuffix(patternText, previousText)
+	default:
+		return false
+	}
+}
+
+// locate returns line and column of the first occurrence of text in data.
+func locate(data []byte, text string) (int, int) {
+	index := bytes.Index(data, []byte(text))
+	if index < 0 {
+		return 0, 0
+	}
+	return position(data, index)
+}
+
+// position converts offset to line and column, both start from 1.
+func position(data []byte, offset int) (int, int) {
+	offset = min(max(offset, 0), len(data))
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// patternText returns pattern as it's written in json.
+func patternText(pattern buildsystem.Pattern) string {
+	if pattern.IsStructured() {
+		return pattern.String()
+	}
+	return quote(pattern.Name)
+}
+
+func quote(text string) string {
+	return strconv.Quote(text)
+}
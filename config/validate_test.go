@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateConfRepo(t *testing.T) {
+	dirs := Dirs
+	confDir := t.TempDir()
+	Dirs.WorkspaceDir = confDir
+	Dirs.PortsDir = filepath.Join(confDir, "ports")
+	Dirs.PlatformsDir = filepath.Join(confDir, "platforms")
+	Dirs.ProjectsDir = filepath.Join(confDir, "projects")
+	Dirs.ToolsDir = filepath.Join(confDir, "tools")
+	defer func() { Dirs = dirs }()
+
+	writeTestPort(t, "zlib@1.3.1", ``)
+	writeTestPortFile(t, "curl@8.5.0", `{
+	"url": "https://example.com/curl.git",
+	"ref": "curl-8_5_0",
+	"build_configs": [
+		{
+			"pattern": "*linux*",
+			"build_tool": "cmake",
+			"env_vars": ["CFLAGS=-fPIC", "-fPIC"],
+			"patches": ["fix-build.patch"],
+			"dependencies": ["zlib@>=1.3", "openssl@3.0.0", "zlib@<1.0"],
+			"cmake_config": "linux_shared",
+			"optons": ["-DBUILD_TESTING=OFF"]
+		},
+		{
+			"pattern": "x86_64-linux-20.04",
+			"build_tool": "cmake"
+		}
+	],
+	"features": {
+		"http~2": {}
+	}
+}`)
+	if err := os.MkdirAll(Dirs.ProjectsDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(Dirs.ProjectsDir, "demo.json"), []byte(`{"ports": ["curl@8.5.0", "zlib"]}`), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := ValidateConfRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]bool{
+		"ports/curl/8.5.0.json:8:33: env var \"-fPIC\" is invalid, it should be like KEY=VALUE":                                              false,
+		"ports/curl/8.5.0.json:9:16: patch file fix-build.patch does not exist":                                                              false,
+		"ports/curl/8.5.0.json:10:35: port openssl does not exist":                                                                           false,
+		"ports/curl/8.5.0.json:10:52: no version of zlib satisfies <1.0":                                                                     false,
+		"ports/curl/8.5.0.json:11:20: cmake_config linux_shared is referred but 8.5.0@cmake_config.json does not exist":                      false,
+		"ports/curl/8.5.0.json:12:4: unknown key \"optons\"":                                                                                 false,
+		"ports/curl/8.5.0.json:15:15: build_config with pattern \"x86_64-linux-20.04\" is unreachable, it's shadowed by pattern \"*linux*\"": false,
+		"projects/demo.json:1:26: port name and version are invalid zlib":                                                                    false,
+		"ports/curl/8.5.0.json:20:3: feature name http~2 is invalid":                                                                         false,
+	}
+	for _, issue := range issues {
+		if _, ok := expected[issue.String()]; !ok {
+			t.Errorf("unexpected issue: %s", issue)
+			continue
+		}
+		expected[issue.String()] = true
+	}
+	for issue, found := range expected {
+		if !found {
+			t.Errorf("missing issue: %s", issue)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"buildenv/pkg/fileio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Kinds of issues found by verify.
+const (
+	FileMissing  = "missing"  // File recorded in state file is removed.
+	FileModified = "modified" // File's sha256 is different from the recorded one.
+	FileUnowned  = "unowned"  // File in installed dir is not recorded by any state file.
+)
+
+// FileIssue is a broken file in installed dir.
+type FileIssue struct {
+	Kind string
+	File string     // Path relative to `installed` dir.
+	Port *StateFile // It's nil for unowned file.
+}
+
+func (f FileIssue) String() string {
+	if f.Port == nil {
+		return fmt.Sprintf("[%s] %s", f.Kind, f.File)
+	}
+	return fmt.Sprintf("[%s] %s (%s)", f.Kind, f.File, f.Port.FullName())
+}
+
+// FullName returns name of installed port like `curl@8.5.0[http2,ssl]`.
+func (s StateFile) FullName() string {
+	return s.Name + "@" + s.Version + formatFeatures(s.Features)
+}
+
+// Verify checks if installed files are missing or modified since installed,
+// sha256 is not checked for state files written by old buildenv.
+func (s *StateFile) Verify() ([]FileIssue, error) {
+	var issues []FileIssue
+	for _, file := range s.Files {
+		path := s.InstalledPath(file)
+		if _, err := os.Lstat(path); err != nil {
+			if os.IsNotExist(err) {
+				issues = append(issues, FileIssue{Kind: FileMissing, File: file, Port: s})
+				continue
+			}
+			return nil, err
+		}
+
+		expected, ok := s.Checksums[file]
+		if !ok {
+			continue
+		}
+		checksum, err := fileio.FileSha256(path)
+		if err != nil {
+			return nil, err
+		}
+		if checksum != expected {
+			issues = append(issues, FileIssue{Kind: FileModified, File: file, Port: s})
+		}
+	}
+
+	return issues, nil
+}
+
+// UnownedFiles returns files under the dirs which are not recorded by any state file.
+func UnownedFiles(dirs []string, states []StateFile) ([]FileIssue, error) {
+	owned := make(map[string]bool)
+	for _, state := range states {
+		for _, file := range state.Files {
+			owned[state.InstalledPath(file)] = true
+		}
+	}
+
+	var issues []FileIssue
+	for _, dir := range dirs {
+		if !fileio.PathExists(dir) {
+			continue
+		}
+
+		if err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || owned[path] {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(Dirs.InstalledDir, path)
+			if err != nil {
+				return err
+			}
+			issues = append(issues, FileIssue{Kind: FileUnowned, File: relPath})
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(issues, func(a, b FileIssue) int {
+		return strings.Compare(a.File, b.File)
+	})
+	return issues, nil
+}
+
+// Repair copies files of port from its package again, package would be restored from cache dirs
+// when it's removed, but port would never be built from source.
+func (p Port) Repair() error {
+	if !fileio.PathExists(p.packageDir) {
+		if len(p.BuildConfigs) == 0 {
+			return fmt.Errorf("package of %s is removed, please install it again", p.FullName())
+		}
+
+		matchedConfig, err := p.MatchedConfig()
+		if err != nil {
+			return err
+		}
+		installed, _, err := p.installFromCache(matchedConfig)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			return fmt.Errorf("package of %s is not found in packages and cache dirs, please install it again", p.FullName())
+		}
+	}
+
+	// Record checksums of repaired files, original source is kept.
+	state, err := ReadStateFile(p.stateFile)
+	if err != nil {
+		return err
+	}
+
+	// Files of dependencies are not touched, they're repaired by themselves.
+	return p.installFromPackage(nil, state.From)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyStateFile(t *testing.T) {
+	installedDir := Dirs.InstalledDir
+	Dirs.InstalledDir = t.TempDir()
+	defer func() { Dirs.InstalledDir = installedDir }()
+
+	// Install files of zlib and a dev port.
+	files := []string{"x86_64-linux^demo^Release/include/zlib.h", "x86_64-linux^demo^Release/lib/libz.a"}
+	for _, file := range append(files, "dev/bin/ninja", "x86_64-linux^demo^Release/include/extra.h") {
+		path := filepath.Join(Dirs.InstalledDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	infoDir := filepath.Join(Dirs.InstalledDir, "buildenv", "info")
+	if err := writeStateFile(filepath.Join(infoDir, "zlib@1.3.1^x86_64-linux^demo^Release.list"), "source", "", files); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStateFile(filepath.Join(infoDir, "ninja@1.12.1^dev.list"), "archive", "", []string{"bin/ninja"}); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := ListStateFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ninja, zlib := states[0], states[1]
+	if len(zlib.Checksums) != 2 || len(ninja.Checksums) != 1 {
+		t.Fatalf("expected checksums of all files, but got %v and %v", zlib.Checksums, ninja.Checksums)
+	}
+
+	for _, state := range states {
+		issues, err := state.Verify()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 0 {
+			t.Fatalf("expected no issues of %s, but got %v", state.FullName(), issues)
+		}
+	}
+
+	// Edit header and remove library by hand.
+	if err := os.WriteFile(filepath.Join(Dirs.InstalledDir, files[0]), []byte("edited"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(Dirs.InstalledDir, files[1])); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := zlib.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, but got %v", issues)
+	}
+	if issues[0].Kind != FileModified || issues[0].File != files[0] {
+		t.Fatalf("unexpected issue: %s", issues[0])
+	}
+	if issues[1].Kind != FileMissing || issues[1].String() != "[missing] "+files[1]+" (zlib@1.3.1)" {
+		t.Fatalf("unexpected issue: %s", issues[1])
+	}
+
+	dirs := []string{filepath.Join(Dirs.InstalledDir, "x86_64-linux^demo^Release"), filepath.Join(Dirs.InstalledDir, "dev")}
+	unowned, err := UnownedFiles(dirs, states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unowned) != 1 || unowned[0].Kind != FileUnowned || unowned[0].File != "x86_64-linux^demo^Release/include/extra.h" {
+		t.Fatalf("unexpected unowned files: %v", unowned)
+	}
+}
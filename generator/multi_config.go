@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InstalledConfig is installed dir of a build type.
+type InstalledConfig struct {
+	BuildType string // for example: Release, Debug.
+	Dir       string // for example: installed/x86_64-linux^project^Release.
+}
+
+// MergeConfigs merges cmake configs installed for different build types into one prefix,
+// so that multi-config generators like `Ninja Multi-Config` can find all of them with one CMAKE_PREFIX_PATH.
+// Files of build type like `<lib>Targets-debug.cmake` are rewritten to import libraries from installed dir
+// of the build type, other cmake files and headers are shared from the first build type.
+// Libraries, executables and pkg-config files are linked from the first build type that has them,
+// so that `find_library`, `find_program` and pkg-config can also resolve them in the merged prefix.
+func MergeConfigs(prefix string, configs []InstalledConfig) error {
+	if len(configs) == 0 {
+		return fmt.Errorf("no installed config to merge")
+	}
+
+	// Always merge from scratch.
+	if err := os.RemoveAll(prefix); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(prefix, os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+
+	for index, config := range configs {
+		relDir, err := filepath.Rel(prefix, config.Dir)
+		if err != nil {
+			return err
+		}
+
+		suffix := "-" + strings.ToLower(config.BuildType) + ".cmake"
+		for _, folder := range []string{"lib", "lib64", "share", "bin"} {
+			rootDir := filepath.Join(config.Dir, folder)
+			if _, err := os.Stat(rootDir); os.IsNotExist(err) {
+				continue
+			}
+
+			if err := filepath.WalkDir(rootDir, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if entry.IsDir() {
+					return nil
+				}
+
+				relPath, err := filepath.Rel(config.Dir, path)
+				if err != nil {
+					return err
+				}
+				if !strings.HasSuffix(entry.Name(), ".cmake") {
+					return linkFile(path, filepath.Join(prefix, relPath))
+				}
+
+				// Files of other build types are ignored, and shared files are from the first build type.
+				perBuildType := strings.HasSuffix(strings.ToLower(entry.Name()), suffix)
+				if !perBuildType && index > 0 {
+					return nil
+				}
+
+				bytes, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+
+				content := string(bytes)
+				if perBuildType {
+					content = strings.ReplaceAll(content, "${_IMPORT_PREFIX}/",
+						"${_IMPORT_PREFIX}/"+filepath.ToSlash(relDir)+"/")
+				}
+
+				destPath := filepath.Join(prefix, relPath)
+				if err := os.MkdirAll(filepath.Dir(destPath), os.ModeDir|os.ModePerm); err != nil {
+					return err
+				}
+				return os.WriteFile(destPath, []byte(content), os.ModePerm)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Headers are the same for all build types.
+	includeDir := filepath.Join(configs[0].Dir, "include")
+	if _, err := os.Stat(includeDir); err == nil {
+		relDir, err := filepath.Rel(prefix, includeDir)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(relDir, filepath.Join(prefix, "include")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// linkFile links file into merged prefix with relative path, file linked before is kept.
+func linkFile(src, dest string) error {
+	if _, err := os.Lstat(dest); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+	relPath, err := filepath.Rel(filepath.Dir(dest), src)
+	if err != nil {
+		return err
+	}
+	return os.Symlink(relPath, dest)
+}
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeConfigs(t *testing.T) {
+	installedDir := t.TempDir()
+
+	var configs []InstalledConfig
+	for _, buildType := range []string{"Release", "Debug"} {
+		dir := filepath.Join(installedDir, "x86_64-linux^test^"+buildType)
+		configs = append(configs, InstalledConfig{BuildType: buildType, Dir: dir})
+
+		cmakeConfig := CMakeConfig{
+			Namespace:  "yaml-cpp",
+			Libname:    "yaml-cpp",
+			Version:    "0.8.0",
+			SystemName: "Linux",
+			BuildType:  buildType,
+			Libtype:    "STATIC",
+			Filename:   "libyaml-cpp.a",
+		}
+		if err := cmakeConfig.Generate(dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "include", "yaml-cpp"), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+
+		// Debug library has its own name, others are the same for all build types.
+		libName := "libyaml-cpp.a"
+		if buildType == "Debug" {
+			libName = "libyaml-cppd.a"
+		}
+		for _, file := range []string{"lib/" + libName, "lib/pkgconfig/yaml-cpp.pc", "bin/yaml-cpp-parse"} {
+			path := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(buildType), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	prefix := filepath.Join(installedDir, "x86_64-linux^test^multi")
+	if err := MergeConfigs(prefix, configs); err != nil {
+		t.Fatal(err)
+	}
+
+	cmakeDir := filepath.Join(prefix, "lib", "cmake", "yaml-cpp")
+	for _, fileName := range []string{"yaml-cppConfig.cmake", "yaml-cppTargets.cmake", "yaml-cppConfigVersion.cmake"} {
+		if _, err := os.Stat(filepath.Join(cmakeDir, fileName)); err != nil {
+			t.Fatalf("expected %s to be merged: %s", fileName, err)
+		}
+	}
+
+	// Libraries are imported from installed dir of every build type.
+	for _, buildType := range []string{"release", "debug"} {
+		bytes, err := os.ReadFile(filepath.Join(cmakeDir, "yaml-cppTargets-"+buildType+".cmake"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		folder := "x86_64-linux^test^Release"
+		if buildType == "debug" {
+			folder = "x86_64-linux^test^Debug"
+		}
+		expected := "${_IMPORT_PREFIX}/../" + folder + "/lib/libyaml-cpp.a"
+		if !strings.Contains(string(bytes), expected) {
+			t.Fatalf("expected %s in yaml-cppTargets-%s.cmake", expected, buildType)
+		}
+	}
+
+	// Libraries, pkg-config files and executables can be resolved in merged prefix.
+	files := map[string]string{
+		"lib/libyaml-cpp.a":         "Release",
+		"lib/libyaml-cppd.a":        "Debug",
+		"lib/pkgconfig/yaml-cpp.pc": "Release",
+		"bin/yaml-cpp-parse":        "Release",
+	}
+	for file, buildType := range files {
+		bytes, err := os.ReadFile(filepath.Join(prefix, file))
+		if err != nil {
+			t.Fatalf("expected %s to be merged: %s", file, err)
+		}
+		if string(bytes) != buildType {
+			t.Fatalf("expected %s from %s, but got %s", file, buildType, bytes)
+		}
+	}
+
+	// Headers are shared from the first build type.
+	target, err := os.Readlink(filepath.Join(prefix, "include"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != filepath.Join("..", "x86_64-linux^test^Release", "include") {
+		t.Fatalf("unexpected include link: %s", target)
+	}
+}
@@ -4,6 +4,10 @@ go 1.22.1
 
 require (
 	github.com/charmbracelet/bubbletea v1.2.4
+	github.com/klauspost/compress v1.18.0
+	github.com/sorairolake/lzip-go v0.3.8
+	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/sys v0.28.0
 	golang.org/x/term v0.27.0
 )
 
@@ -28,6 +32,5 @@ require (
 	github.com/muesli/termenv v0.15.2 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 )
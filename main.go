@@ -4,6 +4,7 @@ import (
 	"buildenv/cmd/cli"
 	"buildenv/cmd/menu"
 	"buildenv/config"
+	"buildenv/pkg/env"
 	"fmt"
 	"log"
 	"os"
@@ -18,7 +19,8 @@ func main() {
 		log.Fatalf("get home directory: %s", err)
 	}
 
-	// Clean environment.
+	// Clean environment, original one is kept for placeholders like ${ENV:HOME}.
+	env.SaveOriginal()
 	os.Clearenv()
 
 	var paths []string
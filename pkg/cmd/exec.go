@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"buildenv/pkg/color"
+	"buildenv/pkg/fileio"
 	"bytes"
 	"fmt"
 	"io"
@@ -16,6 +17,7 @@ type executor struct {
 	command string
 	workDir string
 	logPath string
+	envs    []string // Extra environment variables like `KEY=VALUE`.
 }
 
 func NewExecutor(title, command string) *executor {
@@ -32,6 +34,12 @@ func (e *executor) SetWorkDir(workDir string) *executor {
 	return e
 }
 
+// AppendEnv appends environment variables to the command, they override the inherited ones.
+func (e *executor) AppendEnv(envs ...string) *executor {
+	e.envs = append(e.envs, envs...)
+	return e
+}
+
 func (e *executor) SetLogPath(logPath string) *executor {
 	e.logPath = logPath
 	return e
@@ -55,8 +63,10 @@ func (e executor) Execute() error {
 }
 
 func (e executor) doExecute(buffer *bytes.Buffer) error {
+	// Credentials in command are hidden from console and log.
+	redacted := fileio.Redact(e.command)
 	if e.title != "" {
-		fmt.Print(color.Sprintf(color.Blue, "\n%s: %s\n\n", e.title, e.command))
+		fmt.Print(color.Sprintf(color.Blue, "\n%s: %s\n\n", e.title, redacted))
 	}
 
 	// Create command for windows and unix like.
@@ -68,7 +78,7 @@ func (e executor) doExecute(buffer *bytes.Buffer) error {
 	}
 
 	cmd.Dir = e.workDir
-	cmd.Env = os.Environ()
+	cmd.Env = append(os.Environ(), e.envs...)
 
 	// Create log file if log path specified.
 	if e.logPath != "" {
@@ -84,12 +94,12 @@ func (e executor) doExecute(buffer *bytes.Buffer) error {
 		// Write env variables to log file.
 		var buffer bytes.Buffer
 		for _, envVar := range cmd.Env {
-			buffer.WriteString(envVar + "\n")
+			buffer.WriteString(fileio.Redact(envVar) + "\n")
 		}
 		io.WriteString(logFile, fmt.Sprintf("Environment:\n%s\n", buffer.String()))
 
 		// Write command summary as header content of file.
-		io.WriteString(logFile, fmt.Sprintf("%s: %s\n\n", e.title, e.command))
+		io.WriteString(logFile, fmt.Sprintf("%s: %s\n\n", e.title, redacted))
 
 		cmd.Stdout = io.MultiWriter(os.Stdout, logFile)
 		cmd.Stderr = io.MultiWriter(os.Stderr, logFile)
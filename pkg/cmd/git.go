@@ -8,21 +8,68 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 )
 
+// GitAuth returns environment variables to send auth headers of url, it's empty when no credential matches.
+// Headers are scoped to url as `http.<url>.extraHeader`, so they're never sent to submodules of other hosts,
+// and they're passed by `GIT_CONFIG_*` instead of command line, so they can't be seen in process list.
+func GitAuth(url string) []string {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil
+	}
+
+	headers := fileio.AuthHeaders(url)
+	if len(headers) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// Config entries of current environment are kept.
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+
+	var envs []string
+	for _, key := range keys {
+		envs = append(envs, fmt.Sprintf("GIT_CONFIG_KEY_%d=http.%s.extraHeader", count, url))
+		envs = append(envs, fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s: %s", count, key, headers[key]))
+		count++
+	}
+	return append(envs, fmt.Sprintf("GIT_CONFIG_COUNT=%d", count))
+}
+
+// repoAuth returns environment variables to send auth headers of origin in repo.
+func repoAuth(repoDir string) []string {
+	output, err := exec.Command("git", "-C", repoDir, "remote", "get-url", "origin").Output()
+	if err != nil {
+		return nil
+	}
+	return GitAuth(strings.TrimSpace(string(output)))
+}
+
 func SyncRepo(sourceDir, repoRef, libName string) error {
 	var commands []string
 	commands = append(commands, "git reset --hard && git clean -xfd")
-	commands = append(commands, fmt.Sprintf("git -C %s fetch origin", sourceDir))
-	commands = append(commands, fmt.Sprintf("git -C %s checkout %s", sourceDir, repoRef))
-	commands = append(commands, fmt.Sprintf("git -C %s pull origin %s", sourceDir, repoRef))
+	if fileio.Offline() {
+		// Only local branches and tags can be checked out in offline mode.
+		commands = append(commands, fmt.Sprintf("git -C %s checkout %s", sourceDir, repoRef))
+	} else {
+		commands = append(commands, fmt.Sprintf("git -C %s fetch origin", sourceDir))
+		commands = append(commands, fmt.Sprintf("git -C %s checkout %s", sourceDir, repoRef))
+		commands = append(commands, fmt.Sprintf("git -C %s pull origin %s", sourceDir, repoRef))
+	}
 
 	// Execute clone command.
 	commandLine := strings.Join(commands, " && ")
 	title := fmt.Sprintf("[sync %s]", libName)
 	executor := NewExecutor(title, commandLine)
 	executor.SetWorkDir(sourceDir)
+	executor.AppendEnv(repoAuth(sourceDir)...)
 	if err := executor.Execute(); err != nil {
 		return err
 	}
@@ -45,7 +92,7 @@ func CherryPick(title, sourceDir string, patches []string) error {
 	}
 
 	commandLine := strings.Join(commands, " && ")
-	if err := NewExecutor(title, commandLine).Execute(); err != nil {
+	if err := NewExecutor(title, commandLine).AppendEnv(repoAuth(sourceDir)...).Execute(); err != nil {
 		return err
 	}
 
@@ -67,7 +114,7 @@ func Rebase(title, sourceDir, repoRef string, rebaseRefs []string) error {
 	}
 
 	commandLine := strings.Join(commands, " && ")
-	if err := NewExecutor(title, commandLine).Execute(); err != nil {
+	if err := NewExecutor(title, commandLine).AppendEnv(repoAuth(sourceDir)...).Execute(); err != nil {
 		return err
 	}
 
@@ -146,6 +193,50 @@ func ApplyPatch(repoDir, patchFile string) error {
 	return nil
 }
 
+// RepoCommit returns the commit sha of HEAD in repo.
+func RepoCommit(repoDir string) (string, error) {
+	cmd := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD")
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run git command: %v", err)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// HasCommit checks if commit exists in local repo.
+func HasCommit(repoDir, commit string) bool {
+	return exec.Command("git", "-C", repoDir, "cat-file", "-e", commit+"^{commit}").Run() == nil
+}
+
+// CheckoutCommit checkouts the repo to the specified commit, fetch from origin if commit not found.
+func CheckoutCommit(repoDir, commit, libName string) error {
+	if current, err := RepoCommit(repoDir); err == nil && current == commit {
+		return nil
+	}
+
+	// Commit cannot be fetched in offline mode.
+	if fileio.Offline() && !HasCommit(repoDir, commit) {
+		return fileio.OfflineError{Resource: fmt.Sprintf("commit %s of %s is not fetched", commit, libName)}
+	}
+
+	var commands []string
+	commands = append(commands, fmt.Sprintf("(git -C %s cat-file -e %s^{commit} || git -C %s fetch origin)", repoDir, commit, repoDir))
+	commands = append(commands, fmt.Sprintf("git -C %s checkout %s", repoDir, commit))
+
+	commandLine := strings.Join(commands, " && ")
+	title := fmt.Sprintf("[checkout %s]", libName)
+	if err := NewExecutor(title, commandLine).AppendEnv(repoAuth(repoDir)...).Execute(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func IsRepoModified(repoDir string) (bool, error) {
 	cmd := exec.Command("git", "-C", repoDir, "status", "--porcelain")
 
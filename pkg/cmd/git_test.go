@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"buildenv/pkg/fileio"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGitAuthScope(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	if err := fileio.SetCredentials([]fileio.Credential{{Host: "gitlab.lan", Token: "private-token"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer fileio.SetCredentials(nil)
+
+	envs := GitAuth("https://gitlab.lan/group/repo.git")
+	if len(envs) == 0 {
+		t.Fatal("expected auth of gitlab.lan")
+	}
+
+	// Header is resolved by git the same way as fetching, submodule on another host should not get it.
+	tests := map[string]string{
+		"https://gitlab.lan/group/repo.git/info/refs":      "Authorization: Bearer private-token",
+		"https://github.com/madler/zlib.git/info/refs":     "",
+		"https://gitlab.lan/group/other.git/info/refs":     "",
+		"http://gitlab.lan/group/repo.git/info/refs":       "",
+		"https://gitlab.lan.evil/group/repo.git/info/refs": "",
+	}
+	for url, expected := range tests {
+		command := exec.Command("git", "config", "--get-urlmatch", "http.extraHeader", url)
+		command.Env = append(os.Environ(), envs...)
+		output, _ := command.Output()
+		if header := strings.TrimSpace(string(output)); header != expected {
+			t.Fatalf("expected header %q for %s but got %q", expected, url, header)
+		}
+	}
+
+	if envs := GitAuth("https://github.com/madler/zlib.git"); envs != nil {
+		t.Fatalf("expected no auth of github.com but got %v", envs)
+	}
+}
@@ -0,0 +1,37 @@
+package env
+
+import (
+	"os"
+	"strings"
+)
+
+// originalEnvs is environment of the shell that runs buildenv, since buildenv cleans environment at startup.
+var originalEnvs = make(map[string]string)
+
+// SaveOriginal keeps environment of the shell, it must be called before os.Clearenv().
+func SaveOriginal() {
+	for _, item := range os.Environ() {
+		if key, value, ok := strings.Cut(item, "="); ok {
+			originalEnvs[key] = value
+		}
+	}
+}
+
+// Lookup looks up env in current environment first, then in the environment of the shell.
+func Lookup(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+
+	value, ok := originalEnvs[key]
+	return value, ok
+}
+
+// Original returns environment of the shell as `KEY=VALUE` items.
+func Original() []string {
+	var items []string
+	for key, value := range originalEnvs {
+		items = append(items, key+"="+value)
+	}
+	return items
+}
@@ -1,6 +1,8 @@
 package fileio
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,8 +11,56 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// DownloadOptions controls timeout and retries of downloads.
+type DownloadOptions struct {
+	Timeout time.Duration // Timeout of connecting, waiting response and every read of body.
+	Retries *int          // Times to retry after the first attempt failed, zero disables retries.
+	Backoff time.Duration // Wait before the first retry, it's doubled for every next retry.
+}
+
+var defaultRetries = 3
+
+var downloadOptions = DownloadOptions{
+	Timeout: 30 * time.Second,
+	Retries: &defaultRetries,
+	Backoff: time.Second,
+}
+
+// SetDownloadOptions overrides timeout and retries of downloads,
+// zero timeout and backoff are ignored, so are nil and negative retries.
+func SetDownloadOptions(options DownloadOptions) {
+	if options.Timeout > 0 {
+		downloadOptions.Timeout = options.Timeout
+	}
+	if options.Retries != nil && *options.Retries >= 0 {
+		retries := *options.Retries
+		downloadOptions.Retries = &retries
+	}
+	if options.Backoff > 0 {
+		downloadOptions.Backoff = options.Backoff
+	}
+}
+
+// httpClient returns client for requests without body, like HEAD.
+func httpClient() *http.Client {
+	return &http.Client{Timeout: downloadOptions.Timeout, CheckRedirect: checkRedirect}
+}
+
+// headRequest sends HEAD request with credentials of url.
+func headRequest(url string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	authorize(request)
+	return httpClient().Do(request)
+}
+
 func NewDownloadRequest(url, destDir string) *downloadRequest {
 	return &downloadRequest{
 		url:     url,
@@ -29,18 +79,15 @@ func (d *downloadRequest) SetArchiveName(archiveName string) *downloadRequest {
 	return d
 }
 
+// Download downloads file into `<name>.part` first, then renames it when completed, so that the
+// downloaded file is always complete. Interrupted download would be resumed with `Range` request.
 func (d downloadRequest) Download() (downloadedFile string, err error) {
-	// Read file size.
-	resp, err := http.Get(d.url)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	// Get file name
-	fileName, err := getFileName(d.url)
-	if err != nil {
-		return "", err
+	// Use archive name as file name if specified.
+	fileName := d.archiveName
+	if fileName == "" {
+		if fileName, err = getFileName(d.url); err != nil {
+			return "", err
+		}
 	}
 
 	// Create download directory.
@@ -48,31 +95,179 @@ func (d downloadRequest) Download() (downloadedFile string, err error) {
 		return "", err
 	}
 
-	// Build download file path.
 	downloadedFile = filepath.Join(d.destDir, fileName)
-	file, err := os.Create(downloadedFile)
-	if err != nil {
+	partFile := downloadedFile + ".part"
+
+	retries := *downloadOptions.Retries
+	for attempt := 0; ; attempt++ {
+		err := d.downloadPart(partFile, fileName)
+		if err == nil {
+			break
+		}
+		if attempt >= retries || !retryable(err) {
+			return "", err
+		}
+
+		backoff := downloadOptions.Backoff << attempt
+		fmt.Printf("\n%s: %s, retry in %s (%d/%d).\n", fileName, err, backoff, attempt+1, retries)
+		time.Sleep(backoff)
+	}
+
+	if err := os.Rename(partFile, downloadedFile); err != nil {
 		return "", err
 	}
+	return downloadedFile, nil
+}
+
+// downloadPart appends the rest of file to part file, it's downloaded from scratch
+// when server doesn't support `Range` request.
+func (d downloadRequest) downloadPart(partFile, fileName string) error {
+	var offset int64
+	if info, err := os.Stat(partFile); err == nil {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return err
+	}
+	authorize(request)
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	// Timeout of client would interrupt the whole download, so only connecting and waiting response are limited.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = downloadOptions.Timeout
+	client := http.Client{Transport: transport, CheckRedirect: checkRedirect}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	fileSize := resp.ContentLength
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+		if start != offset {
+			os.Remove(partFile)
+			return fmt.Errorf("unexpected range start %d, expected %d", start, offset)
+		}
+		flags |= os.O_APPEND
+		fileSize = total
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Part file may be completed already, otherwise it's corrupted.
+		if _, total, err := parseContentRange(resp.Header.Get("Content-Range")); err == nil && total == offset {
+			return nil
+		}
+		os.Remove(partFile)
+		return &statusError{code: resp.StatusCode, offset: offset}
+
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// Server ignores `Range`, download from scratch.
+		flags |= os.O_TRUNC
+		offset = 0
+
+	default:
+		return &statusError{code: resp.StatusCode}
+	}
+
+	file, err := os.OpenFile(partFile, flags, os.ModePerm)
+	if err != nil {
+		return err
+	}
 	defer file.Close()
 
-	// Copy to local file with progress.
-	progress := NewProgressBar(fileName, resp.ContentLength)
-	_, err = io.Copy(io.MultiWriter(file, progress), resp.Body)
+	// Copy to local file with progress, download is canceled when no data is received within timeout.
+	progress := NewProgressBar("Downloading", fileName, fileSize)
+	progress.currentSize = offset
+	body := &idleTimeoutReader{reader: resp.Body, timer: time.AfterFunc(downloadOptions.Timeout, cancel)}
+	defer body.timer.Stop()
+
+	written, err := io.Copy(io.MultiWriter(file, progress), body)
 	if err != nil {
-		return "", err
+		return err
 	}
+	if resp.ContentLength > 0 && written < resp.ContentLength {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 
-	// Rename downloaded file if specified and not same as downloaded file.
-	if d.archiveName != "" && d.archiveName != fileName {
-		renameFile := filepath.Join(d.destDir, d.archiveName)
-		if err := os.Rename(downloadedFile, renameFile); err != nil {
-			return "", err
+// statusError is returned when server responds with unexpected status code.
+type statusError struct {
+	code   int
+	offset int64 // Offset of `Range` request, it's zero when downloading from scratch.
+}
+
+func (s statusError) Error() string {
+	return fmt.Sprintf("status code: %d", s.code)
+}
+
+// retryable checks if download can be retried, client errors are never retried except timeout and throttling,
+// and `416` of resumed download, since the corrupted part file is removed and it can be downloaded from scratch.
+func retryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.code == http.StatusRequestTimeout,
+			statusErr.code == http.StatusTooManyRequests:
+			return true
+		case statusErr.code == http.StatusRequestedRangeNotSatisfiable:
+			return statusErr.offset > 0
+		case statusErr.code >= 400 && statusErr.code < 500:
+			return false
 		}
-		downloadedFile = renameFile
+	}
+	return true
+}
+
+// parseContentRange parses header like `bytes 100-199/200` or `bytes */200`.
+func parseContentRange(contentRange string) (start, total int64, err error) {
+	value, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", contentRange)
 	}
 
-	return downloadedFile, nil
+	rangeValue, totalValue, ok := strings.Cut(value, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", contentRange)
+	}
+	if total, err = strconv.ParseInt(totalValue, 10, 64); err != nil {
+		total = -1 // Total size may be unknown, like `bytes 0-99/*`.
+	}
+	if rangeValue == "*" {
+		return -1, total, nil
+	}
+
+	startValue, _, _ := strings.Cut(rangeValue, "-")
+	if start, err = strconv.ParseInt(startValue, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", contentRange)
+	}
+	return start, total, nil
+}
+
+// idleTimeoutReader cancels request when reading is blocked longer than timeout.
+type idleTimeoutReader struct {
+	reader io.Reader
+	timer  *time.Timer
+}
+
+func (i *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := i.reader.Read(p)
+	i.timer.Reset(downloadOptions.Timeout)
+	return n, err
 }
 
 func getFileName(downloadURL string) (string, error) {
@@ -87,7 +282,7 @@ func getFileName(downloadURL string) (string, error) {
 	}
 
 	// Read file name from http header.
-	resp, err := http.Head(downloadURL)
+	resp, err := headRequest(downloadURL)
 	if err != nil {
 		return "", err
 	}
@@ -103,6 +298,7 @@ func getFileName(downloadURL string) (string, error) {
 }
 
 type progressBar struct {
+	action       string
 	fileName     string
 	fileSize     int64
 	currentSize  int64
@@ -110,8 +306,9 @@ type progressBar struct {
 	lastProgress int
 }
 
-func NewProgressBar(fileName string, fileSize int64) *progressBar {
+func NewProgressBar(action, fileName string, fileSize int64) *progressBar {
 	return &progressBar{
+		action:   action,
 		fileName: fileName,
 		fileSize: fileSize,
 		width:    50,
@@ -121,12 +318,25 @@ func NewProgressBar(fileName string, fileSize int64) *progressBar {
 func (p *progressBar) Write(b []byte) (int, error) {
 	n := len(b)
 	p.currentSize += int64(n)
-	progress := int(float64(p.currentSize*100) / float64(p.fileSize))
+	if p.fileSize <= 0 {
+		return n, nil
+	}
+	p.print(int(float64(p.currentSize*100) / float64(p.fileSize)))
+	return n, nil
+}
+
+// Done prints 100% if it's not reached, like trailing bytes of archive are never read.
+func (p *progressBar) Done() {
+	p.currentSize = max(p.currentSize, p.fileSize)
+	p.print(100)
+}
 
+func (p *progressBar) print(progress int) {
 	if progress > p.lastProgress {
 		p.lastProgress = progress
 
-		content := fmt.Sprintf("Downloading: %s -------- %d%% (%s/%s)",
+		content := fmt.Sprintf("%s: %s -------- %d%% (%s/%s)",
+			p.action,
 			p.fileName,
 			progress,
 			formatSize(p.currentSize),
@@ -138,6 +348,4 @@ func (p *progressBar) Write(b []byte) (int, error) {
 			fmt.Println()
 		}
 	}
-
-	return n, nil
 }
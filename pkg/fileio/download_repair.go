@@ -1,12 +1,38 @@
 package fileio
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
+// ArchiveName returns name of archive downloaded from url, archive is always named after the first url,
+// no matter which fallback url or mirror it's actually downloaded from.
+func ArchiveName(url string) string {
+	return filepath.Base(url)
+}
+
+// FindArchive returns path of downloaded archive, archives named after fallback urls and their mirrors
+// are also accepted, since they may be downloaded by hand for offline mode.
+func FindArchive(downloadedDir, archiveName string, urls ...string) (string, bool) {
+	candidates := []string{archiveName}
+	for _, url := range MirrorUrls(urls...) {
+		if name := ArchiveName(url); !slices.Contains(candidates, name) {
+			candidates = append(candidates, name)
+		}
+	}
+
+	for _, name := range candidates {
+		if archivePath := filepath.Join(downloadedDir, name); PathExists(archivePath) {
+			return archivePath, true
+		}
+	}
+	return "", false
+}
+
 func NewDownloadRepair(url, archiveName, folderName, extractTo, downloadedDir string) *DownloadRepair {
 	return &DownloadRepair{
 		url:           url,
@@ -19,16 +45,69 @@ func NewDownloadRepair(url, archiveName, folderName, extractTo, downloadedDir st
 
 type DownloadRepair struct {
 	url           string
+	fallbackUrls  []string
 	archiveName   string
 	folderName    string
 	extractTo     string
 	downloadedDir string
+	sha256        string
+	sha512        string
+}
+
+// SetFallbackUrls adds urls to try in order when the url is not accessible.
+func (d *DownloadRepair) SetFallbackUrls(urls ...string) *DownloadRepair {
+	d.fallbackUrls = urls
+	return d
+}
+
+// SetSha256 makes the archive verified before extracting.
+func (d *DownloadRepair) SetSha256(sha256 string) *DownloadRepair {
+	d.sha256 = strings.ToLower(strings.TrimSpace(sha256))
+	return d
+}
+
+// SetSha512 makes the archive verified before extracting.
+func (d *DownloadRepair) SetSha512(sha512 string) *DownloadRepair {
+	d.sha512 = strings.ToLower(strings.TrimSpace(sha512))
+	return d
 }
 
+// CheckAndRepair tries mirrors and urls in order until one of them is repaired.
 func (d DownloadRepair) CheckAndRepair() error {
+	urls := MirrorUrls(append([]string{d.url}, d.fallbackUrls...)...)
+
+	// All urls are downloaded as the same archive, it's enough to try one in offline mode.
+	if offline && d.url != "" {
+		urls = []string{d.url}
+	}
+	switch len(urls) {
+	case 0:
+		return fmt.Errorf("url of %s is empty", d.archiveName)
+	case 1:
+		return d.checkAndRepair(urls[0])
+	}
+
+	var errs []error
+	for index, url := range urls {
+		if err := d.checkAndRepair(url); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			if index < len(urls)-1 {
+				fmt.Printf("[✘] %s is not available: %s, try next url.\n", url, err)
+			}
+			continue
+		}
+
+		fmt.Printf("[✔] %s is repaired from %s\n", d.archiveName, url)
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d DownloadRepair) checkAndRepair(url string) error {
 	switch {
-	case strings.HasPrefix(d.url, "http"), strings.HasPrefix(d.url, "ftp"):
-		downloaded, err := d.download(d.url, d.archiveName)
+	case strings.HasPrefix(url, "http"), strings.HasPrefix(url, "ftp"):
+		downloaded, err := d.download(url, d.archiveName)
 		if err != nil {
 			return err
 		}
@@ -44,11 +123,11 @@ func (d DownloadRepair) CheckAndRepair() error {
 			return fmt.Errorf("%s: failed to move nested folder: %w", d.folderName, err)
 		}
 
-	case strings.HasPrefix(d.url, "file:///"):
-		localPath := strings.TrimPrefix(d.url, "file:///")
+	case strings.HasPrefix(url, "file:///"):
+		localPath := strings.TrimPrefix(url, "file:///")
 		state, err := os.Stat(localPath)
 		if err != nil {
-			return fmt.Errorf("%s is not accessable", d.url)
+			return fmt.Errorf("%s is not accessable", url)
 		}
 
 		// If localPath is a directory, we assume it is valid.
@@ -56,6 +135,10 @@ func (d DownloadRepair) CheckAndRepair() error {
 			return nil
 		}
 
+		if err := d.verify(localPath); err != nil {
+			return err
+		}
+
 		// Extract archive file.
 		if err := Extract(localPath, filepath.Join(d.extractTo, d.folderName)); err != nil {
 			return fmt.Errorf("%s: extract failed: %w", localPath, err)
@@ -68,7 +151,32 @@ func (d DownloadRepair) CheckAndRepair() error {
 		}
 
 	default:
-		return fmt.Errorf("%s is not accessible", d.url)
+		return fmt.Errorf("%s is not accessible", url)
+	}
+
+	return nil
+}
+
+// verify checks the archive with sha256 and sha512 if they are specified.
+func (d DownloadRepair) verify(archivePath string) error {
+	if d.sha256 != "" {
+		checksum, err := FileSha256(archivePath)
+		if err != nil {
+			return err
+		}
+		if checksum != d.sha256 {
+			return fmt.Errorf("%s: sha256 mismatch, expected %s but got %s", filepath.Base(archivePath), d.sha256, checksum)
+		}
+	}
+
+	if d.sha512 != "" {
+		checksum, err := FileSha512(archivePath)
+		if err != nil {
+			return err
+		}
+		if checksum != d.sha512 {
+			return fmt.Errorf("%s: sha512 mismatch, expected %s but got %s", filepath.Base(archivePath), d.sha512, checksum)
+		}
 	}
 
 	return nil
@@ -91,29 +199,51 @@ func (d *DownloadRepair) MoveAllToParent() error {
 
 func (d DownloadRepair) download(url, archiveName string) (downloaded string, err error) {
 	downloaded = filepath.Join(d.downloadedDir, archiveName)
-	if PathExists(downloaded) {
-		// Redownload if remote file size and local file size not match.
-		fileSize, err := FileSize(url)
-		if err != nil || fileSize <= 0 {
-			return "", fmt.Errorf("get remote filesize failed: %s", url)
+
+	// Remote file size cannot be compared in offline mode, only checksum is verified.
+	if offline {
+		downloaded, ok := FindArchive(d.downloadedDir, archiveName, append([]string{d.url}, d.fallbackUrls...)...)
+		if !ok {
+			return "", OfflineError{Resource: fmt.Sprintf("%s is not downloaded from %s", archiveName, url)}
 		}
-		info, err := os.Stat(downloaded)
-		if err != nil {
-			return "", fmt.Errorf("%s: get local filesize failed: %w", archiveName, err)
+		if err := d.verify(downloaded); err != nil {
+			return "", err
 		}
-		if info.Size() != fileSize {
-			downloadRequest := NewDownloadRequest(url, d.downloadedDir)
-			downloadRequest.SetArchiveName(archiveName)
-			if _, err := downloadRequest.Download(); err != nil {
-				return "", fmt.Errorf("%s: download failed: %w", archiveName, err)
+		return downloaded, nil
+	}
+
+	if PathExists(downloaded) {
+		if d.sha256 != "" || d.sha512 != "" {
+			// Cached file is trusted only when checksum matches, otherwise it's corrupted.
+			if err := d.verify(downloaded); err == nil {
+				return downloaded, nil
+			}
+		} else {
+			// Redownload if remote file size and local file size not match.
+			fileSize, err := FileSize(url)
+			if err != nil || fileSize <= 0 {
+				return "", fmt.Errorf("get remote filesize failed: %s", url)
+			}
+			info, err := os.Stat(downloaded)
+			if err != nil {
+				return "", fmt.Errorf("%s: get local filesize failed: %w", archiveName, err)
+			}
+			if info.Size() == fileSize {
+				return downloaded, nil
 			}
 		}
-	} else {
-		downloadRequest := NewDownloadRequest(url, d.downloadedDir)
-		downloadRequest.SetArchiveName(archiveName)
-		if _, err := downloadRequest.Download(); err != nil {
-			return "", fmt.Errorf("%s: download failed: %w", archiveName, err)
-		}
+	}
+
+	downloadRequest := NewDownloadRequest(url, d.downloadedDir)
+	downloadRequest.SetArchiveName(archiveName)
+	if _, err := downloadRequest.Download(); err != nil {
+		return "", fmt.Errorf("%s: download failed: %w", archiveName, err)
+	}
+
+	// Never keep a file that doesn't match checksum.
+	if err := d.verify(downloaded); err != nil {
+		os.Remove(downloaded)
+		return "", err
 	}
 
 	return downloaded, nil
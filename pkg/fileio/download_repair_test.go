@@ -0,0 +1,130 @@
+package fileio
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadRepairChecksum(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	checksum, err := FileSha256("testdata/test.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupted cached file should be downloaded again.
+	downloadedDir := filepath.Join("temp", "downloads")
+	if err := os.MkdirAll(downloadedDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(downloadedDir, "test.tar.gz"), []byte("corrupted"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	repair := NewDownloadRepair(server.URL+"/test.tar.gz", "test.tar.gz", "test", "temp", downloadedDir)
+	repair.SetSha256(checksum)
+	if err := repair.CheckAndRepair(); err != nil {
+		t.Fatal(err)
+	}
+	if downloaded, err := FileSha256(filepath.Join(downloadedDir, "test.tar.gz")); err != nil || downloaded != checksum {
+		t.Fatalf("corrupted file is not downloaded again: %v", err)
+	}
+	if !PathExists(filepath.Join("temp", "test", "111.txt")) {
+		t.Fatal("archive is not extracted")
+	}
+}
+
+func TestDownloadRepairChecksumMismatch(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	downloadedDir := filepath.Join("temp", "downloads")
+	repair := NewDownloadRepair(server.URL+"/test.tar.gz", "test.tar.gz", "test", "temp", downloadedDir)
+	repair.SetSha512("0000")
+	if err := repair.CheckAndRepair(); err == nil {
+		t.Fatal("expected error when sha512 mismatch")
+	}
+
+	// Mismatched file should neither be kept nor extracted.
+	if PathExists(filepath.Join(downloadedDir, "test.tar.gz")) {
+		t.Fatal("mismatched file should be removed")
+	}
+	if PathExists(filepath.Join("temp", "test")) {
+		t.Fatal("mismatched file should not be extracted")
+	}
+}
+
+func TestDownloadRepairFallbackUrls(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	// Mirror is tried first, then the url, and the fallback url at last.
+	if err := SetMirrors(map[string]string{server.URL + "/": server.URL + "/mirror/"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetMirrors(nil)
+
+	downloadedDir := filepath.Join("temp", "downloads")
+	repair := NewDownloadRepair(server.URL+"/missing/test.tar.gz", "test.tar.gz", "test", "temp", downloadedDir)
+	repair.SetFallbackUrls(server.URL + "/test.tar.gz")
+	if err := repair.CheckAndRepair(); err != nil {
+		t.Fatal(err)
+	}
+	if !PathExists(filepath.Join("temp", "test", "111.txt")) {
+		t.Fatal("archive is not extracted")
+	}
+
+	// All errors are reported when no url is available.
+	repair = NewDownloadRepair(server.URL+"/missing/test.tar.gz", "missing.tar.gz", "missing", "temp", downloadedDir)
+	err := repair.CheckAndRepair()
+	if err == nil {
+		t.Fatal("expected error when no url is available")
+	}
+	if !strings.Contains(err.Error(), server.URL+"/mirror/missing/test.tar.gz") ||
+		!strings.Contains(err.Error(), server.URL+"/missing/test.tar.gz") {
+		t.Fatalf("expected errors of all urls, but got: %s", err)
+	}
+}
+
+func TestDownloadRepairOffline(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	SetOffline(true)
+	defer SetOffline(false)
+
+	// Missing archive is reported without any request.
+	downloadedDir := filepath.Join("temp", "downloads")
+	repair := NewDownloadRepair("http://unreachable.invalid/test.tar.gz", "test.tar.gz", "test", "temp", downloadedDir)
+	err := repair.CheckAndRepair()
+	var offlineErr OfflineError
+	if !errors.As(err, &offlineErr) {
+		t.Fatalf("expected offline error, but got %v", err)
+	}
+
+	// Downloaded archive is used as it is.
+	if err := os.MkdirAll(downloadedDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyFile("testdata/test.tar.gz", filepath.Join(downloadedDir, "test.tar.gz")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repair.CheckAndRepair(); err != nil {
+		t.Fatal(err)
+	}
+	if !PathExists(filepath.Join("temp", "test", "111.txt")) {
+		t.Fatal("archive is not extracted")
+	}
+}
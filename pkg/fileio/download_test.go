@@ -0,0 +1,175 @@
+package fileio
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setTestDownloadOptions(t *testing.T) {
+	options := downloadOptions
+	t.Cleanup(func() { downloadOptions = options })
+	retries := 3
+	downloadOptions = DownloadOptions{Timeout: 200 * time.Millisecond, Retries: &retries, Backoff: time.Millisecond}
+}
+
+func TestDownloadResume(t *testing.T) {
+	setTestDownloadOptions(t)
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	// First request is interrupted in the middle, the rest is requested with `Range`.
+	var requests atomic.Int32
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if requests.Add(1) == 1 {
+			w.Header().Set("Content-Length", "10000")
+			w.Write(data[:4000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "test.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	destDir := t.TempDir()
+	downloaded, err := NewDownloadRequest(server.URL+"/test.bin", destDir).Download()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(downloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, data) {
+		t.Fatalf("unexpected content of %d bytes", len(content))
+	}
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=4000-" {
+		t.Fatalf("unexpected ranges: %q", ranges)
+	}
+	if PathExists(downloaded + ".part") {
+		t.Fatal("part file should be renamed")
+	}
+}
+
+func TestDownloadRetry(t *testing.T) {
+	setTestDownloadOptions(t)
+
+	// Server errors are retried.
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	destDir := t.TempDir()
+	downloaded, err := NewDownloadRequest(server.URL+"/test.txt", destDir).SetArchiveName("renamed.txt").Download()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloaded != filepath.Join(destDir, "renamed.txt") || requests.Load() != 3 {
+		t.Fatalf("unexpected download %s after %d requests", downloaded, requests.Load())
+	}
+
+	// Client errors are never retried.
+	requests.Store(0)
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer notFound.Close()
+
+	if _, err := NewDownloadRequest(notFound.URL+"/missing.txt", destDir).Download(); err == nil {
+		t.Fatal("expected error of missing file")
+	}
+	if requests.Load() != 1 || PathExists(filepath.Join(destDir, "missing.txt")) {
+		t.Fatalf("missing file should not be retried or kept, requests: %d", requests.Load())
+	}
+
+	// `416` of request without `Range` is never retried.
+	requests.Store(0)
+	notSatisfiable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	}))
+	defer notSatisfiable.Close()
+
+	if _, err := NewDownloadRequest(notSatisfiable.URL+"/test.txt", destDir).Download(); err == nil {
+		t.Fatal("expected error of 416")
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("416 without range should not be retried, requests: %d", requests.Load())
+	}
+}
+
+func TestDownloadRetriesDisabled(t *testing.T) {
+	setTestDownloadOptions(t)
+	disabled := 0
+	SetDownloadOptions(DownloadOptions{Retries: &disabled})
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewDownloadRequest(server.URL+"/test.txt", t.TempDir()).Download(); err == nil {
+		t.Fatal("expected error of 503")
+	}
+	if requests.Load() != 1 {
+		t.Fatalf("download should not be retried when retries is 0, requests: %d", requests.Load())
+	}
+}
+
+func TestDownloadIdleTimeout(t *testing.T) {
+	setTestDownloadOptions(t)
+
+	// First response stalls after header, it should be canceled and retried.
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Content-Length", "7")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	downloaded, err := NewDownloadRequest(server.URL+"/test.txt", t.TempDir()).Download()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(downloaded); err != nil || string(content) != "content" {
+		t.Fatalf("unexpected content: %q, %v", content, err)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	for contentRange, expected := range map[string][2]int64{
+		"bytes 100-199/200": {100, 200},
+		"bytes */200":       {-1, 200},
+		"bytes 0-99/*":      {0, -1},
+	} {
+		start, total, err := parseContentRange(contentRange)
+		if err != nil || start != expected[0] || total != expected[1] {
+			t.Fatalf("unexpected range of %q: %d, %d, %v", contentRange, start, total, err)
+		}
+	}
+	if _, _, err := parseContentRange("items 0-1/2"); err == nil {
+		t.Fatal("expected error of invalid Content-Range")
+	}
+}
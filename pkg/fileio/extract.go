@@ -1,98 +1,473 @@
 package fileio
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sorairolake/lzip-go"
+	"github.com/ulikunitz/xz"
 )
 
+// archiveSuffixes are archive types that can be extracted.
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".tar.xz", ".tar.bz2", ".tar.zst", ".tar.lz", ".tar", ".zip", ".7z"}
+
 func IsSupportedArchive(filePath string) bool {
-	return strings.HasSuffix(filePath, ".tar.gz") ||
-		strings.HasSuffix(filePath, ".tar.xz") ||
-		strings.HasSuffix(filePath, ".tar.bz2") ||
-		strings.HasSuffix(filePath, ".zip") ||
-		strings.HasSuffix(filePath, ".7z")
+	return archiveSuffix(filePath) != ""
+}
+
+func archiveSuffix(filePath string) string {
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(filePath, suffix) {
+			return suffix
+		}
+	}
+	return ""
 }
 
+// Extract extracts archive into destDir, entries that escape destDir are rejected.
 func Extract(archiveFile, destDir string) error {
-	fileName := filepath.Base(archiveFile)
-	PrintInline(fmt.Sprintf("\rExtracting: %s...", fileName))
+	suffix := archiveSuffix(archiveFile)
+	if suffix == "" {
+		return fmt.Errorf("unsupported archive file type: %s", archiveFile)
+	}
 
-	var command string
+	if err := os.RemoveAll(destDir); err != nil {
+		return fmt.Errorf("failed to remove directory: %w", err)
+	}
+	if err := os.MkdirAll(destDir, os.ModeDir|os.ModePerm); err != nil {
+		return fmt.Errorf("failed to mkdir for extract: %w", err)
+	}
 
-	switch {
-	case strings.HasSuffix(archiveFile, ".tar.gz"), strings.HasSuffix(archiveFile, ".tgz"):
-		command = fmt.Sprintf("tar -zxvf %s -C %s", archiveFile, destDir)
+	var err error
+	switch suffix {
+	case ".zip":
+		err = extractZip(archiveFile, destDir)
+	case ".7z":
+		err = extract7z(archiveFile, destDir)
+	default:
+		err = extractTar(archiveFile, destDir, suffix)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", filepath.Base(archiveFile), err)
+	}
 
-	case strings.HasSuffix(archiveFile, ".tar.xz"):
-		command = fmt.Sprintf("tar -xvf %s -C %s", archiveFile, destDir)
+	return nil
+}
 
-	case strings.HasSuffix(archiveFile, ".tar.bz2"):
-		command = fmt.Sprintf("tar -xvjf %s -C %s", archiveFile, destDir)
+// extractTar extracts tarball, progress is counted by bytes read from archive file.
+func extractTar(archiveFile, destDir, suffix string) error {
+	file, err := os.Open(archiveFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-	case strings.HasSuffix(archiveFile, ".zip"):
-		command = fmt.Sprintf("unzip %s -d %s", archiveFile, destDir)
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	progress := NewProgressBar("Extracting", filepath.Base(archiveFile), info.Size())
+	reader := io.TeeReader(file, progress)
 
-	case strings.HasSuffix(archiveFile, ".7z"):
-		command = fmt.Sprintf("7z x %s -o %s", archiveFile, destDir)
+	var decompressed io.Reader
+	switch suffix {
+	case ".tar.gz", ".tgz":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		decompressed = gzipReader
 
-	default:
-		return fmt.Errorf("unsupported archive file type: %s", archiveFile)
+	case ".tar.xz":
+		if decompressed, err = xz.NewReader(reader); err != nil {
+			return err
+		}
+
+	case ".tar.bz2":
+		decompressed = bzip2.NewReader(reader)
+
+	case ".tar.zst":
+		zstdReader, err := zstd.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer zstdReader.Close()
+		decompressed = zstdReader
+
+	case ".tar.lz":
+		if decompressed, err = lzip.NewReader(reader); err != nil {
+			return err
+		}
+
+	case ".tar":
+		decompressed = reader
 	}
 
-	if err := os.RemoveAll(destDir); err != nil {
-		return fmt.Errorf("failed to remove directory: %w", err)
+	tarReader := tar.NewReader(decompressed)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := entryPath(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+		mode := header.FileInfo().Mode().Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if err := writeEntry(target, mode, tarReader); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			if err := writeSymlink(target, header.Linkname); err != nil {
+				return err
+			}
+
+		case tar.TypeLink:
+			// Target of hard link is the path of other entry in archive.
+			source, err := entryPath(destDir, header.Linkname)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Link(source, target); err != nil {
+				return err
+			}
+
+		default:
+			// Devices, fifos and pax headers are not required by libraries and tools.
+		}
 	}
-	if err := os.MkdirAll(destDir, os.ModeDir|os.ModePerm); err != nil {
-		return fmt.Errorf("failed to mkdir for extract: %w", err)
+
+	progress.Done()
+	return nil
+}
+
+// extractZip extracts zip, progress is counted by bytes of extracted files.
+func extractZip(archiveFile, destDir string) error {
+	zipReader, err := zip.OpenReader(archiveFile)
+	if err != nil {
+		return err
 	}
+	defer zipReader.Close()
 
-	// Run command.
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", command)
-	} else {
-		cmd = exec.Command("bash", "-c", command)
+	var totalSize int64
+	for _, file := range zipReader.File {
+		totalSize += int64(file.UncompressedSize64)
 	}
+	progress := NewProgressBar("Extracting", filepath.Base(archiveFile), totalSize)
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stdout
-	cmd.Env = os.Environ()
+	for _, file := range zipReader.File {
+		target, err := entryPath(destDir, file.Name)
+		if err != nil {
+			return err
+		}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to extract: %w", err)
+		if err := extractZipEntry(file, destDir, target, progress); err != nil {
+			return err
+		}
 	}
 
+	progress.Done()
 	return nil
 }
 
-// Targz creates a tarball from srcDir and saves it to archivePath.
-func Targz(archivePath, srcDir string, includeFolder bool) error {
-	var cmd *exec.Cmd
-	var command string
+func extractZipEntry(file *zip.File, destDir, target string, progress io.Writer) error {
+	mode := file.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(target, mode.Perm()|0700)
+	}
 
-	if includeFolder {
-		command = fmt.Sprintf("tar -cvzf %s %s", archivePath, srcDir)
-	} else {
-		command = fmt.Sprintf("tar -cvzf %s -C %s .", archivePath, srcDir)
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Content of symlink in zip is its target.
+	if mode&fs.ModeSymlink != 0 {
+		linkname, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		return writeSymlink(target, string(linkname))
 	}
 
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command("cmd", "/c", command)
-	} else {
-		cmd = exec.Command("bash", "-c", command)
+	// Files in zip created on windows may have no permission.
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
 	}
+	return writeEntry(target, perm, io.TeeReader(reader, progress))
+}
 
+// extract7z extracts 7z with `7z` command, since it's not supported by go standard library,
+// it's the only archive that requires external tool, `7z` strips absolute and parent paths of entries itself.
+func extract7z(archiveFile, destDir string) error {
+	PrintInline(fmt.Sprintf("Extracting: %s...", filepath.Base(archiveFile)))
+
+	cmd := exec.Command("7z", "x", archiveFile, "-o"+destDir, "-y")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stdout
 	cmd.Env = os.Environ()
+	return cmd.Run()
+}
+
+// entryPath returns path of entry under destDir, entry that escapes destDir is an error,
+// including the one written through symlink of previous entry.
+func entryPath(destDir, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("entry %s is absolute path", name)
+	}
+
+	target := filepath.Join(destDir, name)
+	if !withinDir(destDir, target) {
+		return "", fmt.Errorf("entry %s escapes destination", name)
+	}
+
+	realDest, err := realPath(destDir)
+	if err != nil {
+		return "", err
+	}
+	realParent, err := realPath(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	if !withinDir(realDest, realParent) {
+		return "", fmt.Errorf("entry %s escapes destination through symlink", name)
+	}
+
+	return target, nil
+}
+
+func withinDir(dir, target string) bool {
+	relPath, err := filepath.Rel(dir, target)
+	if err != nil {
+		return false
+	}
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+// realPath returns path with symlinks evaluated, the part that not exists yet is kept as it is.
+func realPath(path string) (string, error) {
+	existing := path
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// writeEntry writes file of entry with its permission, parent dir is created if not exists.
+func writeEntry(target string, mode fs.FileMode, reader io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+
+	// Remove existing one, it may be a symlink that points to other file.
+	os.Remove(target)
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, reader); err != nil {
+		return err
+	}
+
+	// Permission of created file is masked by umask.
+	return os.Chmod(target, mode)
+}
+
+// writeSymlink creates symlink as it is, even it's absolute or points outside destDir,
+// since later entries written through it are rejected by entryPath.
+func writeSymlink(target, linkname string) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+	os.Remove(target)
+	return os.Symlink(linkname, target)
+}
+
+// Tarball creates a tarball from srcDir and saves it to archivePath, it's compressed according to
+// suffix of archivePath, like `.tar.gz` and `.tar.zst`. Level is compression level of gzip (1~9)
+// or zstd (1~22), the default level is used when it's 0.
+func Tarball(archivePath, srcDir string, includeFolder bool, level int) error {
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModeDir|os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+	defer file.Close()
 
-	if err := cmd.Run(); err != nil {
+	compressor, err := newCompressor(file, archivePath, level)
+	if err != nil {
 		return fmt.Errorf("failed to create tarball: %w", err)
 	}
+	tarWriter := tar.NewWriter(compressor)
 
+	// Entries are prefixed with folder name of srcDir when includeFolder is true.
+	baseDir := srcDir
+	if includeFolder {
+		baseDir = filepath.Dir(srcDir)
+	}
+
+	if err := filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return writeTarEntry(tarWriter, baseDir, path)
+	}); err != nil {
+		compressor.Close()
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("failed to create tarball: %w", err)
+	}
 	return nil
 }
+
+// ValidateCompressionLevel checks if level is valid for compression of archive,
+// it only checks the range, so that no encoder is created.
+func ValidateCompressionLevel(archivePath string, level int) error {
+	switch {
+	case strings.HasSuffix(archivePath, ".tar.gz"), strings.HasSuffix(archivePath, ".tgz"):
+		if level != 0 && (level < gzip.BestSpeed || level > gzip.BestCompression) {
+			return fmt.Errorf("compression level of gzip should be 1~9, but got %d", level)
+		}
+
+	case strings.HasSuffix(archivePath, ".tar.zst"):
+		if level != 0 && (level < 1 || level > 22) {
+			return fmt.Errorf("compression level of zstd should be 1~22, but got %d", level)
+		}
+
+	case strings.HasSuffix(archivePath, ".tar"):
+
+	default:
+		return fmt.Errorf("unsupported tarball type: %s", archivePath)
+	}
+
+	return nil
+}
+
+func newCompressor(writer io.Writer, archivePath string, level int) (io.WriteCloser, error) {
+	if err := ValidateCompressionLevel(archivePath, level); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(archivePath, ".tar.gz"), strings.HasSuffix(archivePath, ".tgz"):
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(writer, level)
+
+	case strings.HasSuffix(archivePath, ".tar.zst"):
+		options := []zstd.EOption{zstd.WithEncoderConcurrency(runtime.NumCPU())}
+		if level != 0 {
+			options = append(options, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(writer, options...)
+
+	default:
+		return nopWriteCloser{writer}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func writeTarEntry(tarWriter *tar.Writer, baseDir, path string) error {
+	relPath, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return err
+	}
+	if relPath == "." {
+		return nil
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	var linkname string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if linkname, err = os.Readlink(path); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, linkname)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(relPath)
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
@@ -1,9 +1,14 @@
 package fileio
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/sorairolake/lzip-go"
 )
 
 func TestExtract7z(t *testing.T) {
@@ -89,3 +94,245 @@ func verifyExtracted() error {
 
 	return nil
 }
+
+func TestExtractRejectEscape(t *testing.T) {
+	tests := map[string][]tarEntry{
+		"parent":         {{name: "../evil.txt", content: "evil"}},
+		"absolute":       {{name: "/tmp/evil.txt", content: "evil"}},
+		"through link":   {{name: "link", linkname: ".."}, {name: "link/evil.txt", content: "evil"}},
+		"nested link":    {{name: "dir/", dir: true}, {name: "dir/link", linkname: ".."}, {name: "dir/link/link2", linkname: ".."}, {name: "link2/evil.txt", content: "evil"}},
+		"hard link":      {{name: "link", hardlink: "../evil.txt"}},
+		"nested escaped": {{name: "test/../../evil.txt", content: "evil"}},
+	}
+
+	for name, entries := range tests {
+		archivePath := filepath.Join(t.TempDir(), "test.tar.gz")
+		if err := writeTarGz(archivePath, entries); err != nil {
+			t.Fatal(err)
+		}
+
+		destDir := filepath.Join(t.TempDir(), "dest")
+		if err := Extract(archivePath, destDir); err == nil {
+			t.Fatalf("expected error of %s", name)
+		}
+		if PathExists(filepath.Join(filepath.Dir(destDir), "evil.txt")) {
+			t.Fatalf("file is written outside of destination by %s", name)
+		}
+	}
+}
+
+func TestExtractKeepSymlinks(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "test.tar.gz")
+	if err := writeTarGz(archivePath, []tarEntry{
+		{name: "absolute", linkname: "/etc"},
+		{name: "outside", linkname: "../sysroot/lib"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "dest")
+	if err := Extract(archivePath, destDir); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{"absolute": "/etc", "outside": "../sysroot/lib"} {
+		if linkname, err := os.Readlink(filepath.Join(destDir, name)); err != nil || linkname != expected {
+			t.Fatalf("expected symlink %s to be kept as %s, but got %s, %v", name, expected, linkname, err)
+		}
+	}
+}
+
+func TestExtractModes(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "test dir", "test.tgz")
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTarGz(archivePath, []tarEntry{
+		{name: "bin/", dir: true},
+		{name: "bin/tool", content: "#!/bin/sh", mode: 0755},
+		{name: "bin/tool-link", linkname: "tool"},
+		{name: "readme.txt", content: "readme", mode: 0600},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !IsSupportedArchive(archivePath) {
+		t.Fatalf("%s should be supported", archivePath)
+	}
+
+	destDir := filepath.Join(t.TempDir(), "dest dir")
+	if err := Extract(archivePath, destDir); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, mode := range map[string]os.FileMode{"bin/tool": 0755, "readme.txt": 0600} {
+		info, err := os.Stat(filepath.Join(destDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Fatalf("expected mode of %s to be %v, but got %v", file, mode, info.Mode().Perm())
+		}
+	}
+	if linkname, err := os.Readlink(filepath.Join(destDir, "bin/tool-link")); err != nil || linkname != "tool" {
+		t.Fatalf("unexpected symlink: %s, %v", linkname, err)
+	}
+}
+
+func TestTargz(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	if err := Extract("testdata/test.tar.gz", "temp"); err != nil {
+		t.Fatal(err)
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "test.tar.gz")
+	if err := Tarball(archivePath, "temp", false, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := Extract(archivePath, "temp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyExtracted(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsSupportedArchive(t *testing.T) {
+	for _, file := range []string{"a.tar.gz", "a.tgz", "a.tar.xz", "a.tar.bz2", "a.tar.zst", "a.tar.lz", "a.tar", "a.zip", "a.7z"} {
+		if !IsSupportedArchive(file) {
+			t.Fatalf("%s should be supported", file)
+		}
+	}
+	if IsSupportedArchive("a.rar") {
+		t.Fatal("a.rar should not be supported")
+	}
+	if err := Extract("a.rar", "temp"); err == nil {
+		t.Fatal("expected error of unsupported archive")
+	}
+}
+
+type tarEntry struct {
+	name     string
+	content  string
+	mode     int64
+	dir      bool
+	linkname string
+	hardlink string
+}
+
+func writeTarGz(archivePath string, entries []tarEntry) error {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	defer gzipWriter.Close()
+	tarWriter := tar.NewWriter(gzipWriter)
+	defer tarWriter.Close()
+
+	for _, entry := range entries {
+		header := tar.Header{Name: entry.name, Mode: entry.mode, Typeflag: tar.TypeReg, Size: int64(len(entry.content))}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		switch {
+		case entry.dir:
+			header.Typeflag, header.Mode = tar.TypeDir, 0755
+		case entry.linkname != "":
+			header.Typeflag, header.Linkname = tar.TypeSymlink, entry.linkname
+		case entry.hardlink != "":
+			header.Typeflag, header.Linkname = tar.TypeLink, entry.hardlink
+		}
+
+		if err := tarWriter.WriteHeader(&header); err != nil {
+			return err
+		}
+		if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestTarballFormats(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	for _, suffix := range []string{".tar", ".tar.zst", ".tgz"} {
+		if err := Extract("testdata/test.tar.gz", "temp"); err != nil {
+			t.Fatal(err)
+		}
+
+		archivePath := filepath.Join(t.TempDir(), "test"+suffix)
+		if err := Tarball(archivePath, "temp/test", true, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := Extract(archivePath, "temp"); err != nil {
+			t.Fatal(err)
+		}
+		if err := verifyExtracted(); err != nil {
+			t.Fatalf("%s: %s", suffix, err)
+		}
+	}
+}
+
+func TestExtractTarLz(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	if err := Extract("testdata/test.tar.gz", "temp"); err != nil {
+		t.Fatal(err)
+	}
+	tarPath := filepath.Join(t.TempDir(), "test.tar")
+	if err := Tarball(tarPath, "temp", false, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Compress tarball with lzip.
+	content, err := os.ReadFile(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "test.tar.lz")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := lzip.NewWriter(file)
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if err := Extract(archivePath, "temp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyExtracted(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateCompressionLevel(t *testing.T) {
+	tests := []struct {
+		archivePath string
+		level       int
+		valid       bool
+	}{
+		{"a.tar.gz", 0, true},
+		{"a.tar.gz", 9, true},
+		{"a.tar.gz", 10, false},
+		{"a.tar.zst", 19, true},
+		{"a.tar.zst", 23, false},
+		{"a.tar.zst", -1, false},
+		{"a.zip", 0, false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateCompressionLevel(test.archivePath, test.level); (err == nil) != test.valid {
+			t.Fatalf("unexpected result of %s with level %d: %v", test.archivePath, test.level, err)
+		}
+	}
+}
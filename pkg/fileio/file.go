@@ -1,7 +1,11 @@
 package fileio
 
 import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
@@ -84,9 +88,9 @@ func CopyDir(srcDir, dstDir string) error {
 	})
 }
 
-// RenameDir rename files in src to dest.
+// RenameDir rename files in src to dest, src is removed after all files are renamed.
 func RenameDir(srcDir, dstDir string) error {
-	return filepath.Walk(srcDir, func(srcPath string, info os.FileInfo, err error) error {
+	if err := filepath.Walk(srcDir, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -101,17 +105,13 @@ func RenameDir(srcDir, dstDir string) error {
 			return os.MkdirAll(dstPath, info.Mode())
 		}
 
-		if err := RenameFile(srcPath, dstPath); err != nil {
-			return err
-		}
-
-		// Try remove parent folder if it's empty.
-		if err := RemoveFolderRecursively(filepath.Dir(srcPath)); err != nil {
-			return fmt.Errorf("cannot remove parent folder: %s", err)
-		}
+		return RenameFile(srcPath, dstPath)
+	}); err != nil {
+		return err
+	}
 
-		return nil
-	})
+	// Only empty folders are left, parent of src is kept since it may be shared by other ports.
+	return os.RemoveAll(srcDir)
 }
 
 // CopyFile copy file from src to dest.
@@ -278,3 +278,27 @@ func RemoveFolderRecursively(path string) error {
 
 	return nil
 }
+
+// FileSha256 calculates sha256 checksum of file.
+func FileSha256(filePath string) (string, error) {
+	return fileChecksum(filePath, sha256.New())
+}
+
+// FileSha512 calculates sha512 checksum of file.
+func FileSha512(filePath string) (string, error) {
+	return fileChecksum(filePath, sha512.New())
+}
+
+func fileChecksum(filePath string, hash hash.Hash) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
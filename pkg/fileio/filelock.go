@@ -0,0 +1,28 @@
+package fileio
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LockFile locks the file exclusively across processes, it blocks until the lock is acquired.
+// The lock is released by calling unlock, or by the system when process exits.
+func LockFile(path string) (unlock func() error, err error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModeDir|os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer file.Close()
+		return unlockFile(file)
+	}, nil
+}
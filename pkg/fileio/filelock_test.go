@@ -0,0 +1,44 @@
+package fileio
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "install.lock")
+
+	unlock, err := LockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second lock is blocked until the first one is released.
+	locked := make(chan func() error)
+	go func() {
+		unlock, err := LockFile(path)
+		if err != nil {
+			t.Error(err)
+		}
+		locked <- unlock
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("expected the second lock to be blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case unlock := <-locked:
+		if unlock != nil {
+			unlock()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the second lock to be acquired")
+	}
+}
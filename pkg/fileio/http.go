@@ -2,7 +2,6 @@ package fileio
 
 import (
 	"fmt"
-	"net/http"
 	"strings"
 )
 
@@ -17,10 +16,8 @@ func CheckAvailable(filePath string) error {
 		return nil
 	}
 
-	client := http.DefaultClient
-
 	// Check URL availability using HEAD request.
-	resp, err := client.Head(filePath)
+	resp, err := headRequest(filePath)
 	if err != nil {
 		return err
 	}
@@ -36,8 +33,7 @@ func CheckAvailable(filePath string) error {
 
 // FileSize returns the size of the file at the given URL.
 func FileSize(url string) (int64, error) {
-	client := http.DefaultClient
-	resp, err := client.Head(url)
+	resp, err := headRequest(url)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,53 @@
+package fileio
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// mirrors maps url prefixes to their mirrors, like `https://github.com/` -> `http://gitmirror.lan/`.
+var mirrors map[string]string
+
+// SetMirrors sets mirrors that are tried before original urls when downloading and cloning.
+func SetMirrors(items map[string]string) error {
+	for prefix, mirror := range items {
+		if strings.TrimSpace(prefix) == "" || strings.TrimSpace(mirror) == "" {
+			return fmt.Errorf("invalid mirror %q -> %q, neither url nor mirror can be empty", prefix, mirror)
+		}
+	}
+
+	mirrors = items
+	return nil
+}
+
+// MirrorUrls returns urls to try in order, the mirror of every url is tried before itself,
+// the longest prefix would be chosen when more than one mirror matches.
+func MirrorUrls(urls ...string) []string {
+	var candidates []string
+	appendUnique := func(url string) {
+		if !slices.Contains(candidates, url) {
+			candidates = append(candidates, url)
+		}
+	}
+
+	for _, url := range urls {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+
+		var matched string
+		for prefix := range mirrors {
+			if strings.HasPrefix(url, prefix) && len(prefix) > len(matched) {
+				matched = prefix
+			}
+		}
+		if matched != "" {
+			appendUnique(mirrors[matched] + strings.TrimPrefix(url, matched))
+		}
+		appendUnique(url)
+	}
+
+	return candidates
+}
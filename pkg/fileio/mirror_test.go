@@ -0,0 +1,38 @@
+package fileio
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMirrorUrls(t *testing.T) {
+	if err := SetMirrors(map[string]string{
+		"https://github.com/":        "http://gitmirror.lan/",
+		"https://github.com/madler/": "http://zlib.lan/",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetMirrors(nil)
+
+	urls := MirrorUrls(
+		"https://github.com/madler/zlib.git",
+		"https://github.com/mirror/zlib.git",
+		"http://gitmirror.lan/mirror/zlib.git",
+		"https://example.com/zlib.tar.gz",
+		"",
+	)
+	expected := []string{
+		"http://zlib.lan/zlib.git",
+		"https://github.com/madler/zlib.git",
+		"http://gitmirror.lan/mirror/zlib.git",
+		"https://github.com/mirror/zlib.git",
+		"https://example.com/zlib.tar.gz",
+	}
+	if !slices.Equal(urls, expected) {
+		t.Fatalf("unexpected urls: %v", urls)
+	}
+
+	if err := SetMirrors(map[string]string{"https://github.com/": ""}); err == nil {
+		t.Fatal("expected error of empty mirror")
+	}
+}
@@ -0,0 +1,99 @@
+package fileio
+
+import (
+	"os"
+	"strings"
+)
+
+// netrcMachines are credentials in netrc, key of default machine is empty.
+var netrcMachines map[string]Credential
+
+// LoadNetrc reads credentials from netrc file like `~/.netrc`, it's ignored when not exists.
+func LoadNetrc(path string) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	netrcMachines = parseNetrc(string(bytes))
+	collectSecrets()
+	return nil
+}
+
+// parseNetrc parses tokens of `machine`, `default`, `login` and `password`, macros are skipped.
+func parseNetrc(content string) map[string]Credential {
+	machines := make(map[string]Credential)
+
+	var (
+		machine  string
+		current  *Credential
+		inMacro  bool
+		lines    = strings.Split(content, "\n")
+		finished = func() {
+			if current != nil && current.Username != "" {
+				machines[machine] = *current
+			}
+			current = nil
+		}
+	)
+
+	for _, line := range lines {
+		// Macro is ended by an empty line.
+		if inMacro {
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for index := 0; index < len(fields); index++ {
+			next := func() string {
+				if index+1 < len(fields) {
+					index++
+					return fields[index]
+				}
+				return ""
+			}
+
+			switch fields[index] {
+			case "machine":
+				finished()
+				machine = next()
+				current = &Credential{Host: machine}
+			case "default":
+				finished()
+				machine = ""
+				current = &Credential{}
+			case "login":
+				if current != nil {
+					current.Username = next()
+				}
+			case "password":
+				if current != nil {
+					current.Password = next()
+				}
+			case "macdef":
+				finished()
+				inMacro = true
+				index = len(fields)
+			}
+		}
+	}
+	finished()
+
+	return machines
+}
+
+// netrcCredential returns credential of host, default machine is used when host is not found.
+func netrcCredential(host string) (Credential, bool) {
+	if credential, ok := netrcMachines[host]; ok {
+		return credential, true
+	}
+	credential, ok := netrcMachines[""]
+	return credential, ok
+}
@@ -0,0 +1,23 @@
+package fileio
+
+// offline makes downloads and clones use local files only.
+var offline bool
+
+// SetOffline enables or disables offline mode, nothing would be downloaded in offline mode.
+func SetOffline(enabled bool) {
+	offline = enabled
+}
+
+// Offline returns if offline mode is enabled.
+func Offline() bool {
+	return offline
+}
+
+// OfflineError is returned when resource is not found locally in offline mode.
+type OfflineError struct {
+	Resource string // Resource that is missing, like `nasm-2.16.03.tar.gz is not downloaded`.
+}
+
+func (o OfflineError) Error() string {
+	return o.Resource + " in offline mode"
+}
@@ -0,0 +1,335 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version, the leading `v` is optional and
+// missing minor or patch would be treated as 0, for example: `v1.2` equals to `1.2.0`.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+
+	original string
+}
+
+// Parse parses version like `1.2.3`, `v1.2.3`, `1.2` and `1.2.3-rc1`.
+func Parse(version string) (Version, error) {
+	v, parts, err := parsePartial(version)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts == 0 {
+		return Version{}, fmt.Errorf("invalid version: %s", version)
+	}
+
+	return v, nil
+}
+
+func (v Version) String() string {
+	if v.original != "" {
+		return v.original
+	}
+
+	if v.Prerelease != "" {
+		return fmt.Sprintf("%d.%d.%d-%s", v.Major, v.Minor, v.Patch, v.Prerelease)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 when v is less than, equal to or greater than other.
+func (v Version) Compare(other Version) int {
+	if result := compareInt(v.Major, other.Major); result != 0 {
+		return result
+	}
+	if result := compareInt(v.Minor, other.Minor); result != 0 {
+		return result
+	}
+	if result := compareInt(v.Patch, other.Patch); result != 0 {
+		return result
+	}
+
+	// A version without prerelease has higher precedence.
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	default:
+		return comparePrerelease(v.Prerelease, other.Prerelease)
+	}
+}
+
+// Constraint is a version range like `>=1.2.11 <1.3`, `^3.0` or `~1.2 || ^2.0`.
+type Constraint struct {
+	text   string
+	groups [][]comparator // groups are OR-ed, comparators inside a group are AND-ed.
+}
+
+// IsConstraint reports whether text contains range operators rather than an exact version.
+func IsConstraint(text string) bool {
+	return strings.ContainsAny(text, "<>=^~*| ,") ||
+		strings.HasSuffix(text, ".x") || strings.HasSuffix(text, ".X")
+}
+
+// ParseConstraint parses a version range, supported operators are
+// `=`, `>`, `>=`, `<`, `<=`, `^`, `~`, `*` and `x` wildcards,
+// comparators separated by space or comma are AND-ed and `||` means OR.
+func ParseConstraint(text string) (Constraint, error) {
+	constraint := Constraint{text: strings.TrimSpace(text)}
+	if constraint.text == "" {
+		return Constraint{}, fmt.Errorf("version constraint is empty")
+	}
+
+	for _, group := range strings.Split(constraint.text, "||") {
+		fields := strings.FieldsFunc(group, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		if len(fields) == 0 {
+			return Constraint{}, fmt.Errorf("invalid version constraint: %s", text)
+		}
+
+		// Join operators separated from their versions, for example: `>= 1.2`.
+		var tokens []string
+		for index := 0; index < len(fields); index++ {
+			field := fields[index]
+			if strings.Trim(field, "<>=^~") == "" && index+1 < len(fields) {
+				field += fields[index+1]
+				index++
+			}
+			tokens = append(tokens, field)
+		}
+
+		var comparators []comparator
+		for _, token := range tokens {
+			parsed, err := parseComparator(token)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", text, err)
+			}
+			comparators = append(comparators, parsed...)
+		}
+		constraint.groups = append(constraint.groups, comparators)
+	}
+
+	return constraint, nil
+}
+
+func (c Constraint) String() string {
+	return c.text
+}
+
+// Check reports whether version satisfies the constraint.
+func (c Constraint) Check(version Version) bool {
+	for _, group := range c.groups {
+		if c.checkGroup(group, version) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraint) checkGroup(group []comparator, version Version) bool {
+	for _, comparator := range group {
+		if !comparator.check(version) {
+			return false
+		}
+	}
+
+	// Prerelease versions only match when any comparator refers to the same [major, minor, patch].
+	if version.Prerelease == "" {
+		return true
+	}
+	for _, comparator := range group {
+		if comparator.version.Prerelease != "" &&
+			comparator.version.Major == version.Major &&
+			comparator.version.Minor == version.Minor &&
+			comparator.version.Patch == version.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+type comparator struct {
+	operator string
+	version  Version
+}
+
+func (c comparator) check(version Version) bool {
+	result := version.Compare(c.version)
+	switch c.operator {
+	case "=":
+		return result == 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	default:
+		return false
+	}
+}
+
+func parseComparator(token string) ([]comparator, error) {
+	var operator string
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(token, candidate) {
+			operator = candidate
+			token = strings.TrimPrefix(token, candidate)
+			break
+		}
+	}
+
+	if token == "*" || token == "x" || token == "X" {
+		return []comparator{{operator: ">=", version: Version{}}}, nil
+	}
+
+	version, parts, err := parsePartial(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upper bound of a partial version, for example: `1.2` means `<1.3.0`.
+	nextPartial := func() Version {
+		switch parts {
+		case 1:
+			return Version{Major: version.Major + 1}
+		default:
+			return Version{Major: version.Major, Minor: version.Minor + 1}
+		}
+	}
+
+	switch operator {
+	case "^":
+		var upper Version
+		switch {
+		case version.Major > 0 || parts == 1:
+			upper = Version{Major: version.Major + 1}
+		case version.Minor > 0 || parts == 2:
+			upper = Version{Minor: version.Minor + 1}
+		default:
+			upper = Version{Patch: version.Patch + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper}}, nil
+
+	case "~":
+		upper := Version{Major: version.Major, Minor: version.Minor + 1}
+		if parts == 1 {
+			upper = Version{Major: version.Major + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper}}, nil
+
+	case "", "=":
+		if parts < 3 {
+			return []comparator{{">=", version}, {"<", nextPartial()}}, nil
+		}
+		return []comparator{{"=", version}}, nil
+
+	case ">":
+		if parts < 3 {
+			return []comparator{{">=", nextPartial()}}, nil
+		}
+		return []comparator{{">", version}}, nil
+
+	case "<=":
+		if parts < 3 {
+			return []comparator{{"<", nextPartial()}}, nil
+		}
+		return []comparator{{"<=", version}}, nil
+
+	default:
+		return []comparator{{operator, version}}, nil
+	}
+}
+
+// parsePartial parses a version which may miss minor and patch, or use `x` and `*` as wildcards,
+// it returns how many numeric parts were specified.
+func parsePartial(text string) (Version, int, error) {
+	original := text
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "v"), "V")
+
+	var version Version
+	version.original = original
+
+	// Build metadata doesn't affect precedence.
+	if index := strings.Index(text, "+"); index >= 0 {
+		text = text[:index]
+	}
+	if index := strings.Index(text, "-"); index >= 0 {
+		version.Prerelease = text[index+1:]
+		text = text[:index]
+		if version.Prerelease == "" {
+			return Version{}, 0, fmt.Errorf("invalid version: %s", original)
+		}
+	}
+
+	fields := strings.Split(text, ".")
+	if len(fields) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version: %s", original)
+	}
+
+	numbers := []*int{&version.Major, &version.Minor, &version.Patch}
+	parts := 0
+	for index, field := range fields {
+		if field == "x" || field == "X" || field == "*" {
+			break
+		}
+
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version: %s", original)
+		}
+		*numbers[index] = number
+		parts++
+	}
+
+	return version, parts, nil
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparePrerelease(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for index := 0; index < len(aParts) && index < len(bParts); index++ {
+		aNumber, aErr := strconv.Atoi(aParts[index])
+		bNumber, bErr := strconv.Atoi(bParts[index])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if result := compareInt(aNumber, bNumber); result != 0 {
+				return result
+			}
+		case aErr == nil:
+			return -1 // Numeric identifiers have lower precedence.
+		case bErr == nil:
+			return 1
+		default:
+			if result := strings.Compare(aParts[index], bParts[index]); result != 0 {
+				return result
+			}
+		}
+	}
+
+	return compareInt(len(aParts), len(bParts))
+}
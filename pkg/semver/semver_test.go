@@ -0,0 +1,99 @@
+package semver
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		result int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.11", "1.2.3", 1},
+		{"1.3.0", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+	}
+
+	for _, test := range tests {
+		a, err := Parse(test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Parse(test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result := a.Compare(b); result != test.result {
+			t.Fatalf("compare %s with %s: expected %d but got %d", test.a, test.b, test.result, result)
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=1.2.11 <1.3", "1.2.11", true},
+		{">=1.2.11 <1.3", "1.2.13", true},
+		{">=1.2.11 <1.3", "1.3.0", false},
+		{">=1.2.11 <1.3", "1.2.8", false},
+		{">=1.2.11, <1.3", "v1.2.12", true},
+		{">= 1.2.11", "1.2.12", true},
+		{"^3.0", "3.4.1", true},
+		{"^3.0", "4.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"~1.2.3", "1.2.2", false},
+		{"1.2.x", "1.2.7", true},
+		{"1.2.x", "1.3.0", false},
+		{"*", "0.0.1", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"=1.2.3", "1.2.3", true},
+		{"~1.2 || ^2.0", "2.5.0", true},
+		{"~1.2 || ^2.0", "1.5.0", false},
+		{">=1.0.0", "1.1.0-rc1", false},
+		{">=1.1.0-rc1", "1.1.0-rc2", true},
+	}
+
+	for _, test := range tests {
+		constraint, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version, err := Parse(test.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result := constraint.Check(version); result != test.expected {
+			t.Fatalf("check %s against %s: expected %v but got %v", test.version, test.constraint, test.expected, result)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, version := range []string{"", "abc", "1.2.3.4", "1.-2"} {
+		if _, err := Parse(version); err == nil {
+			t.Fatalf("expected error when parse %q", version)
+		}
+	}
+
+	for _, constraint := range []string{"", ">=abc", "^1.2 || "} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Fatalf("expected error when parse constraint %q", constraint)
+		}
+	}
+}